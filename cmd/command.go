@@ -0,0 +1,164 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/apex/log"
+	"github.com/jilleJr/flog/pkg/loglevel"
+	"github.com/jilleJr/flog/pkg/logparser"
+	"github.com/jilleJr/flog/pkg/printer"
+)
+
+// runCommand starts a command and filters the logs from its STDOUT and
+// STDERR as two separate streams. Signals are passed on to the command, and
+// flog exits with the command's exit code.
+func runCommand(command []string, filter loglevel.Filter, opts printer.Options) {
+	opts.IdleFlush = flags.idleFlush
+	c := exec.Command(command[0], command[1:]...)
+	c.Stdin = os.Stdin
+	setProcessGroup(c)
+	stdout, err := c.StdoutPipe()
+	if err != nil {
+		fmt.Printf("ERR: Failed to start command: %s: %v\n", command[0], err)
+		os.Exit(exitCodeError)
+	}
+	stderr, err := c.StderrPipe()
+	if err != nil {
+		fmt.Printf("ERR: Failed to start command: %s: %v\n", command[0], err)
+		os.Exit(exitCodeError)
+	}
+	if err := c.Start(); err != nil {
+		fmt.Printf("ERR: Failed to start command: %s: %v\n", command[0], err)
+		os.Exit(exitCodeError)
+	}
+	log.WithField("pid", c.Process.Pid).Debugf("Started command: %s", command[0])
+
+	ch := forwardSignals(c.Process)
+	defer signal.Stop(ch)
+
+	var killOnce sync.Once
+	kill := func() {
+		killOnce.Do(func() {
+			log.Infof("Terminating command on log at or above %s: %s", flags.killOn.Level(), command[0])
+			terminateProcess(c.Process)
+		})
+	}
+
+	var wg sync.WaitGroup
+	for _, stream := range []struct {
+		name string
+		r    io.Reader
+	}{
+		{"stdout", stdout},
+		{"stderr", stderr},
+	} {
+		wg.Add(1)
+		go func(name string, r io.Reader) {
+			defer wg.Done()
+			logread := logparser.NewStreamReader(r, flags.idleFlush)
+			if formatParser != nil {
+				logread.ForceParser(formatParser)
+			}
+			var reader logparser.Reader = logread
+			if killOn := flags.killOn.Level(); killOn != loglevel.Undefined {
+				reader = &killOnReader{Reader: logread, level: killOn, kill: kill}
+			}
+			// Signals are passed on to the command instead of cancelling,
+			// so that the logs are printed until the command has exited.
+			p := newPrinter(context.Background(), name, reader, filter, opts)
+			for p.Next() {
+				checkFailOn(p)
+			}
+			p.PrintOmittedLogs()
+			checkReadError(name, reader)
+		}(stream.name, stream.r)
+	}
+	wg.Wait()
+
+	err = c.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		setExitCode(commandExitCode(exitErr.ProcessState))
+	} else if err != nil {
+		log.WithError(err).Errorf("Failed to wait for command: %s", command[0])
+		setExitCode(exitCodeError)
+	}
+}
+
+// forwardSignals passes on interrupts and termination signals to the
+// process instead of exiting flog, so that flog keeps printing the logs from
+// the process until it has exited. The process runs in its own process
+// group, so Ctrl+C in the terminal only reaches it once, via flog.
+func forwardSignals(process *os.Process) chan os.Signal {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		for sig := range ch {
+			log.Debugf("Passing on signal to command: %s", sig)
+			if err := process.Signal(sig); err != nil {
+				log.WithError(err).Debug("Failed to pass on signal to command")
+			}
+		}
+	}()
+	return ch
+}
+
+// terminateProcess asks the process to terminate, and falls back to killing
+// it on platforms that don't support SIGTERM.
+func terminateProcess(process *os.Process) {
+	if err := process.Signal(syscall.SIGTERM); err != nil {
+		process.Kill()
+	}
+}
+
+// commandExitCode returns the exit code of an exited command, using the
+// shell convention of 128 + the signal number if it was killed by a signal.
+func commandExitCode(state *os.ProcessState) int {
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return state.ExitCode()
+}
+
+// killOnReader calls the kill function when it reads a log at or above a
+// severity, regardless of if the log is included by the filters.
+type killOnReader struct {
+	logparser.Reader
+	level loglevel.Level
+	kill  func()
+}
+
+func (r *killOnReader) Scan() bool {
+	if !r.Reader.Scan() {
+		return false
+	}
+	if r.ParsedLog().Level >= r.level {
+		r.kill()
+	}
+	return true
+}
@@ -19,31 +19,60 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/jilleJr/flog/internal/apex/handlers/console"
+	"github.com/jilleJr/flog/pkg/config"
+	"github.com/jilleJr/flog/pkg/decompress"
 	"github.com/jilleJr/flog/pkg/flagtype"
 	"github.com/jilleJr/flog/pkg/license"
 	"github.com/jilleJr/flog/pkg/loglevel"
 	"github.com/jilleJr/flog/pkg/logparser"
 	"github.com/jilleJr/flog/pkg/printer"
+	"github.com/jilleJr/flog/pkg/stats"
 	"github.com/spf13/cobra"
 )
 
 var flags struct {
 	minLevel       flagtype.LogLevel
 	maxLevel       flagtype.LogLevel
-	minTime        string
-	maxTime        string
+	since          flagtype.Time
+	before         flagtype.Time
 	excludedLevels flagtype.LogLevelMask
 	includedLevels flagtype.LogLevelMask
 	quiet          bool
 	verbose        int
+	afterContext   int
+	beforeContext  int
+	context        int
+	contextTime    time.Duration
+	where          flagtype.Where
+	grep           flagtype.Regexps
+	excludeGrep    flagtype.Regexps
+	output         flagtype.OutputFormat
+	configPath     string
+	format         string
+	levelScheme    flagtype.LevelScheme
+	jsonLevelKey   string
+	jsonTimeKey    string
+	follow         bool
+	idleFlush      time.Duration
+	merge          bool
+	prefix         bool
+	stats          bool
+	histogram      time.Duration
+	failOn         flagtype.LogLevel
+	maxCount       int
+	killOn         flagtype.LogLevel
 
 	completion            flagtype.Shell
 	showCompletionHelp    bool
@@ -53,6 +82,10 @@ var flags struct {
 
 var loggingLevel log.Level
 
+// formatParser is the parser set via --format, or nil to detect the log
+// format of each stream.
+var formatParser logparser.Parser
+
 func setLoggingLevel(quiet bool, v int) {
 	if quiet || v <= 0 {
 		loggingLevel = log.ErrorLevel
@@ -65,8 +98,9 @@ func setLoggingLevel(quiet bool, v int) {
 }
 
 var rootCmd = &cobra.Command{
-	Use:   "flog [flags] [file1.log [file2.log [file3.log]]]",
+	Use:   "flog [flags] [file1.log [file2.log [file3.log]]] [-- command [args...]]",
 	Short: "Filter logs on their serverity (even multiline logs), with automatic detection of log formats",
+	Args:  cobra.ArbitraryArgs,
 
 	Run: func(cmd *cobra.Command, args []string) {
 
@@ -94,14 +128,31 @@ var rootCmd = &cobra.Command{
 			return
 		}
 
-		log.SetHandler(console.New(os.Stderr, "flog: "))
-		setLoggingLevel(flags.quiet, flags.verbose)
+		setupParsing()
+		filter := newFilter()
 
-		filter := loglevel.Filter{
-			MinLevel:      flags.minLevel.Level(),
-			MaxLevel:      flags.maxLevel.Level(),
-			BlacklistMask: flags.excludedLevels.Level(),
-			WhitelistMask: flags.includedLevels.Level(),
+		opts := printer.Options{
+			BeforeContext: flags.beforeContext,
+			AfterContext:  flags.afterContext,
+			ContextTime:   flags.contextTime,
+			Where:         flags.where.Expr(),
+			Grep:          flags.grep.Regexps(),
+			ExcludeGrep:   flags.excludeGrep.Regexps(),
+			Prefix:        flags.prefix,
+			MaxCount:      flags.maxCount,
+		}
+		if flags.follow {
+			opts.IdleFlush = flags.idleFlush
+		}
+		if flags.beforeContext < 0 || flags.afterContext < 0 || flags.context < 0 {
+			fmt.Println("ERR: The --before-context, --after-context, and --context flags cannot be negative")
+			os.Exit(exitCodeError)
+		}
+		if !cmd.Flags().Changed("before-context") {
+			opts.BeforeContext = flags.context
+		}
+		if !cmd.Flags().Changed("after-context") {
+			opts.AfterContext = flags.context
 		}
 
 		log.WithFields(log.Fields{
@@ -109,90 +160,461 @@ var rootCmd = &cobra.Command{
 			"MaxLevel":      filter.MaxLevel,
 			"WhitelistMask": filter.WhitelistMask,
 			"BlacklistMask": filter.BlacklistMask,
+			"Since":         flags.since.String(),
+			"Before":        flags.before.String(),
+			"Where":         flags.where.String(),
+			"Grep":          flags.grep.String(),
+			"ExcludeGrep":   flags.excludeGrep.String(),
 		}).Debugf("Parsed filter")
 
-		if len(args) > 0 {
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			if dash > 0 {
+				fmt.Println("ERR: Cannot read logs from both files and a command")
+				os.Exit(exitCodeError)
+			}
+			if len(args) == 0 {
+				fmt.Println("ERR: Missing command after '--'")
+				os.Exit(exitCodeError)
+			}
+			if flags.follow || flags.merge || flags.stats || flags.histogram != 0 {
+				fmt.Println("ERR: The --follow, --merge, --stats, and --histogram flags cannot be used together with a command")
+				os.Exit(exitCodeError)
+			}
+			runCommand(args, filter, opts)
+			return
+		}
+		if flags.killOn.Level() != loglevel.Undefined {
+			fmt.Println("ERR: The --kill-on flag can only be used together with a command, as in: flog --kill-on=fatal -- mycommand")
+			os.Exit(exitCodeError)
+		}
+
+		if (flags.stats || flags.histogram != 0) && flags.follow {
+			fmt.Println("ERR: The --stats and --histogram flags cannot be used together with --follow")
+			os.Exit(exitCodeError)
+		}
+		if flags.histogram < 0 {
+			fmt.Println("ERR: The --histogram interval must be positive")
+			os.Exit(exitCodeError)
+		}
+		if flags.output == flagtype.OutputFormatCSV && flags.histogram == 0 {
+			fmt.Println("ERR: The csv output format is only supported together with --histogram")
+			os.Exit(exitCodeError)
+		}
+		if flags.stats {
+			printStats(args)
+			return
+		}
+		if flags.histogram > 0 {
+			printHistogram(args, flags.histogram)
+			return
+		}
+
+		if flags.merge && flags.follow {
+			fmt.Println("ERR: The --merge and --follow flags cannot be used together")
+			os.Exit(exitCodeError)
+		}
+
+		ctx := notifyInterruptContext()
+		if len(args) > 0 && flags.follow {
+			followLogsFromFiles(ctx, args, filter, opts)
+		} else if len(args) > 1 && flags.merge {
+			mergeLogsFromFiles(ctx, args, filter, opts)
+		} else if len(args) > 0 {
 			for _, path := range args {
-				printLogsFromFile(path, filter)
+				if ctx.Err() != nil {
+					break
+				}
+				printLogsFromFile(ctx, path, filter, opts)
 			}
 		} else {
-			printLogsFromIO("STDIN", os.Stdin, filter)
+			printLogsFromIO(ctx, "STDIN", os.Stdin, filter, opts)
 		}
 	},
 }
 
+// setupParsing sets up logging and the log parsers from the flags that are
+// shared by all commands.
+func setupParsing() {
+	log.SetHandler(console.New(os.Stderr, "flog: "))
+	setLoggingLevel(flags.quiet, flags.verbose)
+
+	if err := addParsersFromConfig(flags.configPath); err != nil {
+		log.WithError(err).Error("Failed to load config")
+		os.Exit(exitCodeError)
+	}
+
+	logparser.ReplaceParser(logparser.JSONParser{
+		LevelScheme: flags.levelScheme.Scheme(),
+		LevelKey:    flags.jsonLevelKey,
+		TimeKey:     flags.jsonTimeKey,
+	})
+
+	if flags.format != "" {
+		parser, ok := logparser.FindParser(flags.format)
+		if !ok {
+			log.Errorf("Unknown log format %q, must be one of: %s", flags.format, strings.Join(logparser.ParserNames(), ", "))
+			os.Exit(exitCodeError)
+		}
+		formatParser = parser
+	}
+}
+
+func newFilter() loglevel.Filter {
+	return loglevel.Filter{
+		MinLevel:      flags.minLevel.Level(),
+		MaxLevel:      flags.maxLevel.Level(),
+		BlacklistMask: flags.excludedLevels.Level(),
+		WhitelistMask: flags.includedLevels.Level(),
+		Since:         flags.since.Time(),
+		Before:        flags.before.Time(),
+	}
+}
+
 func Execute(appVersion string) {
 	rootCmd.Version = appVersion
 	rootCmd.SetVersionTemplate(license.VersionNotice(appVersion))
 	rootCmd.Long = fmt.Sprintf(`Use flog to filter logs on their serverity (even multiline logs),
 with automatic detection of log formats.
 
+Logs are read from the given files, or from STDIN if no files are given.
+A file named "view" is read as the view command, so pass it as a path
+instead, such as "flog ./view".
+Any arguments after '--' are run as a command, and the logs written to its
+STDOUT and STDERR are filtered as two separate streams.
+
+Exit status:
+  0  No errors, and no logs matched the --fail-on severity
+  1  A log matched the --fail-on severity
+  2  Failed to read the logs, or invalid flags
+When running a command, flog exits with the command's exit code instead,
+unless any of the above codes is higher.
+
 %s
 `, license.LicenceNotice(appVersion))
 	err := rootCmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		os.Exit(exitCodeError)
 	}
+	os.Exit(getExitCode())
+}
+
+// Exit codes, as documented in the help text.
+const (
+	exitCodeOK     = 0
+	exitCodeFailOn = 1
+	exitCodeError  = 2
+)
+
+var exitCode = struct {
+	sync.Mutex
+	code int
+}{code: exitCodeOK}
+
+// setExitCode sets the exit code of the program, unless a higher exit code
+// has already been set.
+func setExitCode(code int) {
+	exitCode.Lock()
+	defer exitCode.Unlock()
+	if code > exitCode.code {
+		exitCode.code = code
+	}
+}
+
+func getExitCode() int {
+	exitCode.Lock()
+	defer exitCode.Unlock()
+	return exitCode.code
 }
 
 func init() {
-	rootCmd.Flags().VarP(&flags.minLevel, "min", "s", "Omit logs below specified severity (exclusive)")
+	rootCmd.PersistentFlags().VarP(&flags.minLevel, "min", "s", "Omit logs below specified severity (exclusive)")
 	rootCmd.RegisterFlagCompletionFunc("min", flagtype.CompleteLogLevel)
-	rootCmd.Flags().VarP(&flags.maxLevel, "max", "S", "Omit logs above specified severity (exclusive)")
+	rootCmd.PersistentFlags().VarP(&flags.maxLevel, "max", "S", "Omit logs above specified severity (exclusive)")
 	rootCmd.RegisterFlagCompletionFunc("max", flagtype.CompleteLogLevel)
-	rootCmd.Flags().StringVarP(&flags.minTime, "since", "t", "", "Omit logs timestamped before a specific time (or relative time period ago) [Not yet implemented]")
-	rootCmd.Flags().StringVarP(&flags.minTime, "before", "T", "", "Omit logs timestamped after a specific time (or relative time period ago) [Not yet implemented]")
-	rootCmd.Flags().VarP(&flags.excludedLevels, "exclude", "e", "Omit logs of specified severity (can be specified multiple times)")
+	rootCmd.PersistentFlags().VarP(&flags.since, "since", "t", `Omit logs timestamped before a specific time (or relative time period ago, ex: "15m", "2h", or "3d")`)
+	rootCmd.PersistentFlags().VarP(&flags.before, "before", "T", `Omit logs timestamped after a specific time (or relative time period ago, ex: "15m", "2h", or "3d")`)
+	rootCmd.PersistentFlags().VarP(&flags.excludedLevels, "exclude", "e", "Omit logs of specified severity (can be specified multiple times)")
 	rootCmd.RegisterFlagCompletionFunc("exclude", flagtype.CompleteLogLevel)
-	rootCmd.Flags().VarP(&flags.includedLevels, "include", "i", "Omit logs of severity not specified with this flag (can be specified multiple times)")
+	rootCmd.PersistentFlags().VarP(&flags.includedLevels, "include", "i", "Omit logs of severity not specified with this flag (can be specified multiple times)")
 	rootCmd.RegisterFlagCompletionFunc("include", flagtype.CompleteLogLevel)
+	rootCmd.Flags().VarP(&flags.where, "where", "w", `Omit logs whose fields do not match expression (can be specified multiple times, ex: 'status >= 500 and service == "billing"')`)
+
+	rootCmd.Flags().VarP(&flags.grep, "grep", "g", "Omit logs that do not match any of the specified regex patterns (can be specified multiple times)")
+	rootCmd.Flags().VarP(&flags.excludeGrep, "exclude-grep", "G", "Omit logs that match any of the specified regex patterns (can be specified multiple times)")
+	rootCmd.Flags().Var(&flags.failOn, "fail-on", "Exit with code 1 if any log at or above specified severity matched the filters")
+	rootCmd.RegisterFlagCompletionFunc("fail-on", flagtype.CompleteLogLevel)
+	rootCmd.Flags().Var(&flags.killOn, "kill-on", "Terminate the command given after '--' if it writes any log at or above specified severity")
+	rootCmd.RegisterFlagCompletionFunc("kill-on", flagtype.CompleteLogLevel)
+	rootCmd.Flags().IntVar(&flags.maxCount, "max-count", 0, "Stop reading after number of logs matched the filters")
+	rootCmd.Flags().IntVarP(&flags.afterContext, "after-context", "A", 0, "Print number of omitted logs after each matching log")
+	rootCmd.Flags().IntVarP(&flags.beforeContext, "before-context", "B", 0, "Print number of omitted logs before each matching log")
+	rootCmd.Flags().IntVarP(&flags.context, "context", "C", 0, "Print number of omitted logs before and after each matching log")
+	rootCmd.Flags().DurationVar(&flags.contextTime, "context-time", 0, `Print omitted logs timestamped within a duration before and after each matching log (ex: "5s")`)
+
+	flags.output = flagtype.OutputFormatRaw
+	rootCmd.Flags().VarP(&flags.output, "output", "o", `Output format (for "raw", "json", "logfmt", or "csv")`)
+	rootCmd.RegisterFlagCompletionFunc("output", flagtype.CompleteOutputFormat)
+
+	rootCmd.PersistentFlags().StringVar(&flags.configPath, "config", "", "Load additional log parsers from config file (default ~/.config/flog/config.yaml)")
+
+	rootCmd.PersistentFlags().StringVar(&flags.format, "format", "", "Parse logs using the log format with this name, instead of detecting it")
+	rootCmd.RegisterFlagCompletionFunc("format", completeFormat)
+
+	rootCmd.PersistentFlags().Var(&flags.levelScheme, "json-level-scheme", `Map numeric levels in JSON logs using scheme (for "auto", "bunyan", "otel", or "syslog")`)
+	rootCmd.RegisterFlagCompletionFunc("json-level-scheme", flagtype.CompleteLevelScheme)
+	rootCmd.PersistentFlags().StringVar(&flags.jsonLevelKey, "json-level-key", "", `Read the level in JSON logs from this key instead of the default keys, where nested keys are separated by dots (ex: "log.level")`)
+	rootCmd.PersistentFlags().StringVar(&flags.jsonTimeKey, "json-time-key", "", `Read the timestamp in JSON logs from this key instead of the default keys, where nested keys are separated by dots (ex: "meta.time")`)
+
+	rootCmd.Flags().BoolVarP(&flags.merge, "merge", "m", false, "Interleave the logs from all files in chronological order, instead of printing one file after another")
+	rootCmd.Flags().BoolVarP(&flags.prefix, "prefix", "H", false, "Prefix each printed line with the name of the file it was read from, such as '[app.log] '")
+	rootCmd.Flags().BoolVar(&flags.stats, "stats", false, "Print a summary of log levels, timestamps, and log formats instead of the logs, ignoring any filters (as a table, or JSON with --output=json)")
+	rootCmd.Flags().DurationVar(&flags.histogram, "histogram", 0, `Print a chart of the number of logs per level in time buckets of this size instead of the logs, ignoring any filters (ex: "1m", or CSV with --output=csv)`)
+	rootCmd.Flags().BoolVarP(&flags.follow, "follow", "f", false, "Keep reading logs as they are written to the files, and reopen the files if they are rotated")
+	rootCmd.Flags().DurationVar(&flags.idleFlush, "idle-flush", logparser.DefaultIdleFlush, "When using --follow, a command, or reading from a pipe, print incomplete logs and 'omitted logs' messages after no logs have been written for this duration")
 
-	rootCmd.Flags().BoolVarP(&flags.quiet, "quiet", "q", flags.quiet, "Omit the 'omitted logs' messages. Shorthand for --verbose=0")
-	rootCmd.Flags().CountVarP(&flags.verbose, "verbose", "v", "Enable verbose output (can be specified up to 2 times, ex: --verbose=2 or -vv)")
+	rootCmd.PersistentFlags().BoolVarP(&flags.quiet, "quiet", "q", flags.quiet, "Omit the 'omitted logs' messages. Shorthand for --verbose=0")
+	rootCmd.PersistentFlags().CountVarP(&flags.verbose, "verbose", "v", "Enable verbose output (can be specified up to 2 times, ex: --verbose=2 or -vv)")
 
 	rootCmd.Flags().Bool("version", false, "Show the program's version and then exit")
 	rootCmd.Flags().Bool("help", false, "Show this help text and then exit")
 	rootCmd.Flags().BoolVar(&flags.showCompletionHelp, "help-completion", false, "Show help for generating shell completions and then exit")
 	rootCmd.Flags().Var(&flags.completion, "completion", `Generate shell completions (for "bash", "zsh", "fish", or "powershell")`)
 	rootCmd.RegisterFlagCompletionFunc("completion", flagtype.CompleteShell)
+	// Completions are generated using the --completion flag instead of
+	// cobra's default "completion" subcommand.
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
 	rootCmd.Flags().BoolVar(&flags.showLicenseConditions, "license-c", false, "Show the program's license conditions and then exit. (Warn: a lot of text)")
 	rootCmd.Flags().MarkHidden("license-c")
 	rootCmd.Flags().BoolVar(&flags.showLicenseWarranty, "license-w", false, "Show the program's warranty and then exit")
 	rootCmd.Flags().MarkHidden("license-w")
 }
 
-func printLogsFromFile(path string, filter loglevel.Filter) {
+func completeFormat(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	addParsersFromConfig(flags.configPath)
+	return logparser.ParserNames(), cobra.ShellCompDirectiveNoFileComp
+}
+
+func addParsersFromConfig(path string) error {
+	var cfg config.Config
+	var err error
+	if path != "" {
+		cfg, err = config.Load(path)
+	} else {
+		cfg, err = config.LoadDefault()
+	}
+	if err != nil {
+		return err
+	}
+	for _, p := range cfg.Parsers {
+		parser, err := p.RegExParser()
+		if err != nil {
+			return err
+		}
+		logparser.AddParser(parser, p.Priority)
+		log.WithField("priority", p.Priority).Debugf("Added parser from config: %s", p.Name)
+	}
+	return nil
+}
+
+func printLogsFromFile(ctx context.Context, path string, filter loglevel.Filter, opts printer.Options) {
 	if file, err := os.Open(path); err != nil {
 		fmt.Printf("ERR: Failed to open file: %s: %v\n", path, err)
-		os.Exit(1)
+		os.Exit(exitCodeError)
 	} else {
 		defer file.Close()
-		printLogsFromIO(file.Name(), file, filter)
+		printLogsFromIO(ctx, file.Name(), file, filter, opts)
 	}
 }
 
-func printLogsFromIO(name string, r io.Reader, filter loglevel.Filter) {
-	logread := logparser.NewIOReader(r)
+func followLogsFromFiles(ctx context.Context, paths []string, filter loglevel.Filter, opts printer.Options) {
+	var readers []*logparser.FollowReader
+	for _, path := range paths {
+		r, err := logparser.NewFollowReader(path, flags.idleFlush)
+		if err != nil {
+			fmt.Printf("ERR: Failed to open file: %s: %v\n", path, err)
+			os.Exit(exitCodeError)
+		}
+		if formatParser != nil {
+			r.ForceParser(formatParser)
+		}
+		readers = append(readers, r)
+	}
+	var wg sync.WaitGroup
+	for i, r := range readers {
+		wg.Add(1)
+		go func(name string, r *logparser.FollowReader) {
+			defer wg.Done()
+			defer r.Close()
+			printLogs(ctx, name, r, filter, opts)
+		}(paths[i], r)
+	}
+	wg.Wait()
+}
 
-	p := printer.NewConsolePrinter(name, &logread, filter, loggingLevel)
-	ch := setupCloseHandler(p)
-	defer close(ch)
+func printLogsFromIO(ctx context.Context, name string, r io.Reader, filter loglevel.Filter, opts printer.Options) {
+	if !isRegularFile(r) {
+		opts.IdleFlush = flags.idleFlush
+	}
+	logread, closer := newIOReader(name, r)
+	defer closer.Close()
+	printLogs(ctx, name, logread, filter, opts)
+}
 
-	for p.Next() {
+func mergeLogsFromFiles(ctx context.Context, paths []string, filter loglevel.Filter, opts printer.Options) {
+	var readers []logparser.Reader
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Printf("ERR: Failed to open file: %s: %v\n", path, err)
+			os.Exit(exitCodeError)
+		}
+		defer file.Close()
+		logread, closer := newIOReader(path, file)
+		defer closer.Close()
+		readers = append(readers, logread)
+	}
+	merged := logparser.NewMergeReader(paths, readers)
+	printLogs(ctx, strings.Join(paths, ", "), merged, filter, opts)
+}
+
+func printStats(paths []string) {
+	s := stats.New()
+	readAllLogs(paths, s.AddAll)
+	var err error
+	if flags.output == flagtype.OutputFormatJSON {
+		err = s.WriteJSON(os.Stdout)
+	} else {
+		err = s.WriteTable(os.Stdout)
+	}
+	if err != nil {
+		fmt.Printf("ERR: Failed to write stats: %v\n", err)
+		os.Exit(exitCodeError)
+	}
+}
+
+func printHistogram(paths []string, interval time.Duration) {
+	h := stats.NewHistogram(interval)
+	readAllLogs(paths, h.AddAll)
+	var err error
+	if flags.output == flagtype.OutputFormatCSV {
+		err = h.WriteCSV(os.Stdout)
+	} else {
+		err = h.WriteChart(os.Stdout, histogramWidth)
+	}
+	if err != nil {
+		fmt.Printf("ERR: Failed to write histogram: %v\n", err)
+		os.Exit(exitCodeError)
 	}
 }
 
-// Thanks https://golangcode.com/handle-ctrl-c-exit-in-terminal/
-// His site shows 404, but the source code is supposed to be found here:
-// https://github.com/eddturtle/golangcode-site
-func setupCloseHandler(p printer.Printer) chan<- os.Signal {
-	ch := make(chan os.Signal)
-	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
-	go func(p printer.Printer) {
-		if _, ok := <-ch; ok {
-			p.PrintOmittedLogs()
-			os.Exit(0)
+// histogramWidth is the length of the longest bar in the --histogram chart.
+const histogramWidth = 50
+
+// readAllLogs passes a reader for each file, or STDIN if no files are given,
+// to the addAll function.
+func readAllLogs(paths []string, addAll func(logparser.Reader)) {
+	if len(paths) == 0 {
+		logread, closer := newIOReader("STDIN", os.Stdin)
+		addAll(logread)
+		closer.Close()
+		checkReadError("STDIN", logread)
+	}
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Printf("ERR: Failed to open file: %s: %v\n", path, err)
+			os.Exit(exitCodeError)
 		}
-	}(p)
-	return ch
+		logread, closer := newIOReader(path, file)
+		addAll(logread)
+		closer.Close()
+		checkReadError(path, logread)
+		file.Close()
+	}
+}
+
+// newIOReader decompresses the input if needed. Inputs that are not regular
+// files, such as STDIN when piped from "kubectl logs -f", are read as
+// streams, where logs are completed after the input has been idle for the
+// --idle-flush duration instead of waiting for more lines.
+func newIOReader(name string, r io.Reader) (logparser.Reader, io.Closer) {
+	dr, format, err := decompress.NewReader(r)
+	if err != nil {
+		fmt.Printf("ERR: Failed to decompress: %s: %v\n", name, err)
+		os.Exit(exitCodeError)
+	}
+	if format != decompress.None {
+		log.WithField("format", string(format)).Debugf("Decompressing %s", name)
+	}
+	if !isRegularFile(r) {
+		stream := logparser.NewStreamReader(dr, flags.idleFlush)
+		if formatParser != nil {
+			stream.ForceParser(formatParser)
+		}
+		return stream, dr
+	}
+	logread := logparser.NewIOReader(dr)
+	if formatParser != nil {
+		logread.ForceParser(formatParser)
+	}
+	return &logread, dr
+}
+
+func isRegularFile(r io.Reader) bool {
+	file, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode().IsRegular()
+}
+
+func printLogs(ctx context.Context, name string, r logparser.Reader, filter loglevel.Filter, opts printer.Options) {
+	p := newPrinter(ctx, name, r, filter, opts)
+	for p.Next() {
+		checkFailOn(p)
+	}
+	p.PrintOmittedLogs()
+	if ctx.Err() == nil {
+		// The reader may still be in use in the background if cancelled
+		checkReadError(name, r)
+	}
+}
+
+func checkFailOn(p printer.Printer) {
+	failOn := flags.failOn.Level()
+	if failOn != loglevel.Undefined && p.MatchedLevels() >= failOn {
+		setExitCode(exitCodeFailOn)
+	}
+}
+
+func checkReadError(name string, r logparser.Reader) {
+	if err := r.Err(); err != nil {
+		log.WithError(err).Errorf("Failed to read logs from: %s", name)
+		setExitCode(exitCodeError)
+	}
+}
+
+func newPrinter(ctx context.Context, name string, r logparser.Reader, filter loglevel.Filter, opts printer.Options) printer.Printer {
+	switch flags.output {
+	case flagtype.OutputFormatJSON:
+		return printer.NewJSONPrinter(ctx, os.Stdout, log.Log, name, r, filter, opts)
+	case flagtype.OutputFormatLogfmt:
+		return printer.NewLogfmtPrinter(ctx, os.Stdout, log.Log, name, r, filter, opts)
+	default:
+		return printer.NewConsolePrinter(ctx, os.Stdout, log.Log, name, r, filter, opts)
+	}
+}
+
+// notifyInterruptContext returns a context that is cancelled on Ctrl+C or
+// SIGTERM, so the printers can print their "Omitted logs" messages before
+// exiting. Any further signals are not caught, so a second Ctrl+C exits
+// immediately.
+func notifyInterruptContext() context.Context {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx
 }
@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/apex/log"
+	"github.com/jilleJr/flog/pkg/tui"
+	"github.com/spf13/cobra"
+)
+
+var viewCmd = &cobra.Command{
+	Use:   "view [flags] [file.log]",
+	Short: "Browse logs in an interactive terminal viewer",
+	Long: `Browse logs in a full-screen terminal viewer, where the severities can be
+toggled on and off. Logs are read from the file, or from STDIN if no file is
+given, and filtered using the same severity and time flags as flog.
+
+Keys:
+  up/down, j/k, pgup/pgdn  Move between logs
+  home/end, g/G            Go to the first or last log
+  enter, space             Expand or collapse a multiline log
+  0-8                      Toggle Unknown, Trace, Debug, Information, Warning,
+                           Error, Critical, Fatal, or Panic logs
+  /                        Search incrementally (enter to confirm, esc to cancel)
+  n/N                      Go to the next or previous search match
+  e/E                      Go to the next or previous Error log, or above
+  q, esc                   Quit`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		setupParsing()
+		filter := newFilter()
+
+		name, file := "STDIN", os.Stdin
+		if len(args) > 0 {
+			f, err := os.Open(args[0])
+			if err != nil {
+				fmt.Printf("ERR: Failed to open file: %s: %v\n", args[0], err)
+				os.Exit(exitCodeError)
+			}
+			defer f.Close()
+			name, file = args[0], f
+		}
+		logread, closer := newIOReader(name, file)
+		defer closer.Close()
+
+		if err := tui.Run(name, logread, filter); err != nil {
+			log.WithError(err).Errorf("Failed to view logs from: %s", name)
+			setExitCode(exitCodeError)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(viewCmd)
+}
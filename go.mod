@@ -10,14 +10,24 @@ require (
 	github.com/acarl005/stripansi v0.0.0-20180116102854-5a71ef0e047d
 	github.com/alecthomas/kong v0.2.12
 	github.com/apex/log v1.9.0
+	github.com/gdamore/tcell/v2 v2.5.3
+	github.com/klauspost/compress v1.15.9
+	github.com/mattn/go-runewidth v0.0.13
 	github.com/sirupsen/logrus v1.7.0
 	github.com/spf13/cobra v1.5.0
 	github.com/spf13/pflag v1.0.5
+	github.com/ulikunitz/xz v0.5.10
 	gopkg.in/guregu/null.v3 v3.5.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
+	github.com/gdamore/encoding v1.0.0 // indirect
 	github.com/inconshreveable/mousetrap v1.0.0 // indirect
+	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/pkg/errors v0.8.1 // indirect
-	golang.org/x/sys v0.0.0-20191026070338-33540a1f6037 // indirect
+	github.com/rivo/uniseg v0.2.0 // indirect
+	golang.org/x/sys v0.0.0-20220318055525-2edf467146b5 // indirect
+	golang.org/x/term v0.0.0-20201210144234-2321bbc49cbf // indirect
+	golang.org/x/text v0.3.7 // indirect
 )
@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package config loads flog's configuration file, which by default is found
+// at ~/.config/flog/config.yaml.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/jilleJr/flog/pkg/logparser"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Parsers []Parser `yaml:"parsers"`
+}
+
+// Parser is a user-defined regex parser, such as:
+//
+//	parsers:
+//	  - name: my-app
+//	    regex: '^\[({date})\] (\w+): .*$'
+//	    timestamp: 1
+//	    level: 2
+//	    timeLayout: "2006-01-02 15:04:05"
+//	    priority: 10
+//
+// The name must not be used by another parser, including the built-in ones.
+// The timestamp and level groups can be referenced either by their index or
+// by their name. If omitted, then the groups named "time" and "level" are
+// used. Parsers with a priority above 0 are tried before the built-in
+// parsers, while the rest are tried after.
+type Parser struct {
+	Name       string `yaml:"name"`
+	Regex      string `yaml:"regex"`
+	Timestamp  string `yaml:"timestamp"`
+	Level      string `yaml:"level"`
+	TimeLayout string `yaml:"timeLayout"`
+	Priority   int    `yaml:"priority"`
+}
+
+// DefaultPath returns the path to the configuration file that is used if
+// no other is specified.
+func DefaultPath() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "flog", "config.yaml"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "flog", "config.yaml"), nil
+}
+
+// Load reads and parses a configuration file.
+func Load(path string) (Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return Config{}, fmt.Errorf("parse YAML: %w", err)
+	}
+	// Names must be unique, as parsers are found by name via --format
+	names := map[string]bool{}
+	for _, name := range logparser.ParserNames() {
+		names[name] = true
+	}
+	for i, p := range cfg.Parsers {
+		if _, err := p.RegExParser(); err != nil {
+			return Config{}, fmt.Errorf("parsers[%d]: %w", i, err)
+		}
+		if names[p.Name] {
+			return Config{}, fmt.Errorf("parsers[%d]: parser name %q is already in use", i, p.Name)
+		}
+		names[p.Name] = true
+	}
+	return cfg, nil
+}
+
+// LoadDefault loads the configuration file from the default path, or
+// returns an empty config if the file does not exist.
+func LoadDefault() (Config, error) {
+	path, err := DefaultPath()
+	if err != nil {
+		return Config{}, nil
+	}
+	cfg, err := Load(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Config{}, nil
+	}
+	return cfg, err
+}
+
+// RegExParser compiles the parser configuration into a parser.
+func (p Parser) RegExParser() (logparser.RegExParser, error) {
+	if p.Name == "" {
+		return logparser.RegExParser{}, errors.New("missing parser name")
+	}
+	re, err := logparser.CompileRegexp(p.Regex)
+	if err != nil {
+		return logparser.RegExParser{}, fmt.Errorf("parser %q: %w", p.Name, err)
+	}
+	groupTimestamp, err := groupIndex(re, p.Timestamp)
+	if err != nil {
+		return logparser.RegExParser{}, fmt.Errorf("parser %q: timestamp: %w", p.Name, err)
+	}
+	groupLevel, err := groupIndex(re, p.Level)
+	if err != nil {
+		return logparser.RegExParser{}, fmt.Errorf("parser %q: level: %w", p.Name, err)
+	}
+	return logparser.RegExParser{
+		ParserName:     p.Name,
+		Expression:     re,
+		TimeLayout:     p.TimeLayout,
+		GroupTimestamp: groupTimestamp,
+		GroupLevel:     groupLevel,
+	}, nil
+}
+
+func groupIndex(re *regexp.Regexp, group string) (int, error) {
+	if group == "" {
+		return 0, nil
+	}
+	if index, err := strconv.Atoi(group); err == nil {
+		if index < 1 || index > re.NumSubexp() {
+			return 0, fmt.Errorf("group index %d out of range 1...%d", index, re.NumSubexp())
+		}
+		return index, nil
+	}
+	index := re.SubexpIndex(group)
+	if index == -1 {
+		return 0, fmt.Errorf("no group named %q", group)
+	}
+	return index, nil
+}
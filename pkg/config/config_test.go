@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(path, []byte(`
+parsers:
+  - name: by-index
+    regex: '^\[({date})\] (\w+): .*$'
+    timestamp: 1
+    level: 2
+    priority: 10
+  - name: by-name
+    regex: '^(?P<lvl>\w+) (?P<ts>{date}) .*$'
+    timestamp: ts
+    level: lvl
+    timeLayout: "2006-01-02 15:04:05"
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Parsers) != 2 {
+		t.Fatalf("wrong number of parsers\nwanted: %d\ngot:    %d", 2, len(cfg.Parsers))
+	}
+
+	byIndex, err := cfg.Parsers[0].RegExParser()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if byIndex.GroupTimestamp != 1 || byIndex.GroupLevel != 2 {
+		t.Errorf("wrong group indexes\nwanted: timestamp=1 level=2\ngot:    timestamp=%d level=%d", byIndex.GroupTimestamp, byIndex.GroupLevel)
+	}
+	if cfg.Parsers[0].Priority != 10 {
+		t.Errorf("wrong priority\nwanted: %d\ngot:    %d", 10, cfg.Parsers[0].Priority)
+	}
+
+	byName, err := cfg.Parsers[1].RegExParser()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if byName.GroupTimestamp != 2 || byName.GroupLevel != 1 {
+		t.Errorf("wrong group indexes\nwanted: timestamp=2 level=1\ngot:    timestamp=%d level=%d", byName.GroupTimestamp, byName.GroupLevel)
+	}
+}
+
+func TestParser_RegExParser_errors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		parser Parser
+	}{
+		{
+			name:   "missing name",
+			parser: Parser{Regex: `^(\w+)`},
+		},
+		{
+			name:   "invalid regex",
+			parser: Parser{Name: "foo", Regex: `^(\w+`},
+		},
+		{
+			name:   "group index out of range",
+			parser: Parser{Name: "foo", Regex: `^(\w+)`, Level: "2"},
+		},
+		{
+			name:   "unknown group name",
+			parser: Parser{Name: "foo", Regex: `^(?P<lvl>\w+)`, Level: "level"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.parser.RegExParser(); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestLoad_duplicateNames(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config string
+	}{
+		{
+			name: "duplicate",
+			config: `
+parsers:
+  - name: my-app
+    regex: '^(\w+)'
+  - name: my-app
+    regex: '^(\w+)'
+`,
+		},
+		{
+			name: "built-in",
+			config: `
+parsers:
+  - name: json
+    regex: '^(\w+)'
+    priority: 10
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tc.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load(path); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
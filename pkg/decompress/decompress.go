@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package decompress detects compressed input from its magic bytes and
+// transparently decompresses it.
+package decompress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// Format is a compression format.
+type Format string
+
+const (
+	// None means the input is not compressed.
+	None  Format = ""
+	Gzip  Format = "gzip"
+	Zstd  Format = "zstd"
+	Bzip2 Format = "bzip2"
+	Xz    Format = "xz"
+)
+
+var magics = []struct {
+	format Format
+	magic  []byte
+}{
+	{Gzip, []byte{0x1f, 0x8b}},
+	{Zstd, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	{Bzip2, []byte("BZh")},
+	{Xz, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+}
+
+const maxMagicLen = 6
+
+// NewReader peeks at the start of r and, if it begins with the magic bytes
+// of a known compression format, returns a reader that decompresses it.
+// Uncompressed input is returned as-is. The returned reader should be closed
+// to release any decompressor resources, but closing it does not close r.
+func NewReader(r io.Reader) (io.ReadCloser, Format, error) {
+	buf := bufio.NewReader(r)
+	head, err := buf.Peek(maxMagicLen)
+	if err != nil && err != io.EOF {
+		return nil, None, err
+	}
+	format := Detect(head)
+	switch format {
+	case Gzip:
+		gz, err := gzip.NewReader(buf)
+		if err != nil {
+			return nil, format, err
+		}
+		return gz, format, nil
+	case Zstd:
+		dec, err := zstd.NewReader(buf)
+		if err != nil {
+			return nil, format, err
+		}
+		return dec.IOReadCloser(), format, nil
+	case Bzip2:
+		return io.NopCloser(bzip2.NewReader(buf)), format, nil
+	case Xz:
+		xr, err := xz.NewReader(buf)
+		if err != nil {
+			return nil, format, err
+		}
+		return io.NopCloser(xr), format, nil
+	default:
+		return io.NopCloser(buf), None, nil
+	}
+}
+
+// Detect returns the compression format that the given leading bytes
+// belong to, or None if they don't match any known format.
+func Detect(head []byte) Format {
+	for _, m := range magics {
+		if !bytes.HasPrefix(head, m.magic) {
+			continue
+		}
+		// "BZh" is plain text, so also require the block size digit
+		// to not mistake a log line starting with "BZh" for bzip2.
+		if m.format == Bzip2 && (len(head) < 4 || head[3] < '1' || head[3] > '9') {
+			continue
+		}
+		return m.format
+	}
+	return None
+}
@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package decompress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+const testLogs = "INFO: hello\nERROR: world\n"
+
+func TestNewReader(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input []byte
+		want  Format
+	}{
+		{"plain", []byte(testLogs), None},
+		{"plain starting with BZh", []byte("BZhello\n"), None},
+		{"empty", nil, None},
+		{"gzip", compressGzip(t, testLogs), Gzip},
+		{"zstd", compressZstd(t, testLogs), Zstd},
+		{"xz", compressXz(t, testLogs), Xz},
+		{"bzip2", []byte{
+			66, 90, 104, 57, 49, 65, 89, 38, 83, 89, 87, 220, 252, 94, 0, 0, 4,
+			95, 128, 0, 16, 64, 0, 0, 16, 3, 33, 144, 0, 6, 68, 144, 128, 32, 0,
+			49, 76, 0, 19, 66, 38, 141, 168, 61, 71, 162, 35, 13, 194, 11, 147,
+			1, 202, 94, 153, 134, 170, 31, 23, 114, 69, 56, 80, 144, 87, 220,
+			252, 94,
+		}, Bzip2},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, format, err := NewReader(bytes.NewReader(tc.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer r.Close()
+			if format != tc.want {
+				t.Errorf("wrong format\nwanted: %q\ngot:    %q", tc.want, format)
+			}
+			b, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			want := testLogs
+			if tc.want == None {
+				want = string(tc.input)
+			}
+			if string(b) != want {
+				t.Errorf("wrong content\nwanted: %q\ngot:    %q", want, string(b))
+			}
+		})
+	}
+}
+
+func compressGzip(t *testing.T, s string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	writeAndClose(t, w, s)
+	return buf.Bytes()
+}
+
+func compressZstd(t *testing.T, s string) []byte {
+	var buf bytes.Buffer
+	w, err := zstd.NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeAndClose(t, w, s)
+	return buf.Bytes()
+}
+
+func compressXz(t *testing.T, s string) []byte {
+	var buf bytes.Buffer
+	w, err := xz.NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeAndClose(t, w, s)
+	return buf.Bytes()
+}
+
+func writeAndClose(t *testing.T, w io.WriteCloser, s string) {
+	if _, err := io.WriteString(w, s); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
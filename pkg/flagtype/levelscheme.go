@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package flagtype
+
+import (
+	"fmt"
+
+	"github.com/jilleJr/flog/pkg/loglevel"
+	"github.com/spf13/cobra"
+)
+
+type LevelScheme loglevel.NumericScheme
+
+func (s *LevelScheme) Scheme() loglevel.NumericScheme {
+	return loglevel.NumericScheme(*s)
+}
+
+// String is used both by fmt.Print and by Cobra in help text
+func (s *LevelScheme) String() string {
+	return s.Scheme().String()
+}
+
+// Set must have pointer receiver so it doesn't change the value of a copy
+func (s *LevelScheme) Set(v string) error {
+	scheme, ok := loglevel.ParseNumericScheme(v)
+	if !ok {
+		return fmt.Errorf(`invalid level scheme: %q, must be one of "auto", "bunyan", "otel", or "syslog"`, v)
+	}
+	*s = LevelScheme(scheme)
+	return nil
+}
+
+// Type is only used in help text
+func (s *LevelScheme) Type() string {
+	return "scheme"
+}
+
+func CompleteLevelScheme(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return []string{
+		"auto\tDetect the scheme from the value, when only one scheme uses it",
+		"bunyan\tbunyan and pino levels: 10 trace, 20 debug, 30 info, 40 warn, 50 error, 60 fatal",
+		"otel\tOpenTelemetry severity numbers, from 1 (trace) to 24 (fatal)",
+		"syslog\tsyslog severities, from 0 (emerg) to 7 (debug)",
+	}, cobra.ShellCompDirectiveNoFileComp
+}
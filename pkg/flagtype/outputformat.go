@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package flagtype
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+type OutputFormat string
+
+const (
+	OutputFormatRaw    OutputFormat = "raw"
+	OutputFormatJSON   OutputFormat = "json"
+	OutputFormatLogfmt OutputFormat = "logfmt"
+	OutputFormatCSV    OutputFormat = "csv"
+)
+
+// String is used both by fmt.Print and by Cobra in help text
+func (f *OutputFormat) String() string {
+	return string(*f)
+}
+
+// Set must have pointer receiver so it doesn't change the value of a copy
+func (f *OutputFormat) Set(v string) error {
+	switch strings.ToLower(v) {
+	case "raw":
+		*f = OutputFormatRaw
+	case "json":
+		*f = OutputFormatJSON
+	case "logfmt":
+		*f = OutputFormatLogfmt
+	case "csv":
+		*f = OutputFormatCSV
+	default:
+		return fmt.Errorf(`invalid output format: %q, must be one of "raw", "json", "logfmt", or "csv"`, v)
+	}
+	return nil
+}
+
+// Type is only used in help text
+func (f *OutputFormat) Type() string {
+	return "format"
+}
+
+func CompleteOutputFormat(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return []string{
+		"raw\tPrint logs as-is",
+		"json\tPrint logs as normalized JSON objects, one per line",
+		"logfmt\tPrint logs as logfmt key-value pairs, one per line",
+		"csv\tPrint the --histogram as comma-separated values",
+	}, cobra.ShellCompDirectiveNoFileComp
+}
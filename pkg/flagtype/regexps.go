@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package flagtype
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+type Regexps []*regexp.Regexp
+
+// Ensure it conforms to the interface
+var _ pflag.SliceValue = &Regexps{}
+var _ pflag.Value = &Regexps{}
+
+func (s *Regexps) Regexps() []*regexp.Regexp {
+	return *s
+}
+
+func (s *Regexps) String() string {
+	return "[" + strings.Join(s.GetSlice(), ",") + "]"
+}
+
+func (s *Regexps) Set(str string) error {
+	return s.Append(str)
+}
+
+func (s *Regexps) Type() string {
+	return "regex"
+}
+
+// Append adds the specified value to the end of the flag value list.
+func (s *Regexps) Append(str string) error {
+	re, err := regexp.Compile(str)
+	if err != nil {
+		return err
+	}
+	*s = append(*s, re)
+	return nil
+}
+
+// Replace will fully overwrite any data currently in the flag value list.
+func (s *Regexps) Replace(slice []string) error {
+	var regexps Regexps
+	for _, str := range slice {
+		if err := regexps.Append(str); err != nil {
+			return err
+		}
+	}
+	*s = regexps
+	return nil
+}
+
+// GetSlice returns the flag value list as an array of strings.
+func (s *Regexps) GetSlice() []string {
+	if s == nil {
+		return nil
+	}
+	slice := make([]string, len(*s))
+	for i, re := range *s {
+		slice[i] = re.String()
+	}
+	return slice
+}
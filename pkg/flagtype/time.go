@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package flagtype
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/jilleJr/flog/pkg/logparser"
+	"github.com/spf13/pflag"
+)
+
+// Time is either an absolute timestamp, or a relative time period ago,
+// such as "15m", "2h", or "3d".
+type Time time.Time
+
+// Ensure it conforms to the interface
+var _ pflag.Value = &Time{}
+
+func (t *Time) Time() time.Time {
+	return time.Time(*t)
+}
+
+func (t *Time) String() string {
+	if t.Time().IsZero() {
+		return ""
+	}
+	return t.Time().Format(time.RFC3339Nano)
+}
+
+func (t *Time) Set(str string) error {
+	if d, err := parseRelativeDuration(str); err == nil {
+		*t = Time(time.Now().Add(-d))
+		return nil
+	}
+	if ts := logparser.ParseTimestamp(str); ts.Valid {
+		*t = Time(ts.Time)
+		return nil
+	}
+	return fmt.Errorf("invalid time or relative duration: %q", str)
+}
+
+func (t *Time) Type() string {
+	return "time"
+}
+
+var relativeDurationRegex = regexp.MustCompile(`^(?:(\d+)w)?(?:(\d+)d)?(.*)$`)
+
+// parseRelativeDuration is like time.ParseDuration, but also accepts days
+// and weeks as leading units, such as "3d" or "1w2d12h".
+func parseRelativeDuration(str string) (time.Duration, error) {
+	groups := relativeDurationRegex.FindStringSubmatch(str)
+	if groups == nil || str == "" {
+		return 0, fmt.Errorf("invalid duration: %q", str)
+	}
+	var d time.Duration
+	if groups[1] != "" {
+		weeks, err := strconv.Atoi(groups[1])
+		if err != nil {
+			return 0, err
+		}
+		d += time.Duration(weeks) * 7 * 24 * time.Hour
+	}
+	if groups[2] != "" {
+		days, err := strconv.Atoi(groups[2])
+		if err != nil {
+			return 0, err
+		}
+		d += time.Duration(days) * 24 * time.Hour
+	}
+	if groups[3] != "" {
+		rest, err := time.ParseDuration(groups[3])
+		if err != nil {
+			return 0, err
+		}
+		d += rest
+	}
+	return d, nil
+}
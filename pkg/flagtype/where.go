@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package flagtype
+
+import (
+	"strings"
+
+	"github.com/jilleJr/flog/pkg/where"
+	"github.com/spf13/pflag"
+)
+
+// Where is a list of field filtering expressions, where all must match.
+type Where struct {
+	exprs []where.Expr
+	strs  []string
+}
+
+// Ensure it conforms to the interface
+var _ pflag.Value = &Where{}
+
+// Expr returns all expressions combined, or nil if none were specified.
+func (w *Where) Expr() where.Expr {
+	return where.All(w.exprs...)
+}
+
+func (w *Where) String() string {
+	return strings.Join(w.strs, " and ")
+}
+
+func (w *Where) Set(str string) error {
+	expr, err := where.Parse(str)
+	if err != nil {
+		return err
+	}
+	w.exprs = append(w.exprs, expr)
+	w.strs = append(w.strs, str)
+	return nil
+}
+
+func (w *Where) Type() string {
+	return "expr"
+}
@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package flog_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jilleJr/flog/pkg/flog"
+	"github.com/jilleJr/flog/pkg/loglevel"
+)
+
+func ExampleNewFilterReader() {
+	input := `info: Program[0]
+      Starting up
+fail: Program[0]
+      System.Exception: Something broke
+         at Program.Main(String[] args) in Program.cs:line 12
+info: Program[0]
+      Shutting down`
+
+	r := flog.NewFilterReader(strings.NewReader(input), loglevel.Filter{MinLevel: loglevel.Warning})
+	io.Copy(os.Stdout, r)
+
+	// Output:
+	// fail: Program[0]
+	//       System.Exception: Something broke
+	//          at Program.Main(String[] args) in Program.cs:line 12
+}
+
+func ExampleNewFilterWriter() {
+	w := flog.NewFilterWriter(os.Stdout, loglevel.Filter{BlacklistMask: loglevel.Debug})
+	fmt.Fprintln(w, `{"level":"debug","message":"Loading config"}`)
+	fmt.Fprintln(w, `{"level":"info","message":"Starting up"}`)
+	fmt.Fprint(w, `{"level":"debug",`)
+	fmt.Fprintln(w, `"message":"Written in two parts"}`)
+	fmt.Fprintln(w, `{"level":"error","message":"Something broke"}`)
+	if err := w.Close(); err != nil {
+		fmt.Println("error:", err)
+	}
+
+	// Output:
+	// {"level":"info","message":"Starting up"}
+	// {"level":"error","message":"Something broke"}
+}
@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package flog filters logs on their severity in-process, using the same log
+// format detection and filtering as the flog command-line tool.
+package flog
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/jilleJr/flog/pkg/loglevel"
+	"github.com/jilleJr/flog/pkg/logparser"
+)
+
+// Match returns true if the log is kept by the filter. Logs without a
+// timestamp are never omitted by the filter's time range.
+func Match(log logparser.ParsedLog, f loglevel.Filter) bool {
+	return f.Includes(log.Level, log.Timestamp.Time, log.Timestamp.Valid)
+}
+
+type filterReader struct {
+	logs   *logparser.StreamReader
+	filter loglevel.Filter
+	buf    bytes.Buffer
+}
+
+// NewFilterReader returns a reader that only passes through the logs from r
+// that match the filter. Multiline logs, such as stack traces, are kept or
+// omitted as a whole.
+//
+// The log format is detected from the first lines, and multiline logs are
+// only complete once the next log has started, so logs may be returned after
+// reading a few more lines, after r has not been written to for
+// logparser.DefaultIdleFlush, or after reaching the end of r.
+func NewFilterReader(r io.Reader, f loglevel.Filter) io.Reader {
+	return &filterReader{
+		logs:   logparser.NewStreamReader(r, logparser.DefaultIdleFlush),
+		filter: f,
+	}
+}
+
+func (r *filterReader) Read(p []byte) (int, error) {
+	for r.buf.Len() == 0 {
+		if !r.logs.Scan() {
+			if err := r.logs.Err(); err != nil {
+				return 0, err
+			}
+			return 0, io.EOF
+		}
+		if log := r.logs.ParsedLog(); Match(log, r.filter) {
+			r.buf.WriteString(log.String)
+			r.buf.WriteByte('\n')
+		}
+	}
+	return r.buf.Read(p)
+}
+
+type filterWriter struct {
+	pipe      *io.PipeWriter
+	done      chan error
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewFilterWriter returns a writer that only passes through the logs that
+// match the filter to w. Writes are split into lines, and may contain
+// partial or multiple logs.
+//
+// Logs are written to w in the background once they are complete, which is
+// when the next log has started, when nothing has been written for
+// logparser.DefaultIdleFlush, or when the writer is closed. Close returns
+// any error from writing to w.
+func NewFilterWriter(w io.Writer, f loglevel.Filter) io.WriteCloser {
+	pr, pw := io.Pipe()
+	fw := &filterWriter{
+		pipe: pw,
+		done: make(chan error, 1),
+	}
+	go func() {
+		_, err := io.Copy(w, NewFilterReader(pr, f))
+		pr.CloseWithError(err)
+		fw.done <- err
+	}()
+	return fw
+}
+
+func (w *filterWriter) Write(p []byte) (int, error) {
+	return w.pipe.Write(p)
+}
+
+func (w *filterWriter) Close() error {
+	w.closeOnce.Do(func() {
+		w.pipe.Close()
+		w.closeErr = <-w.done
+	})
+	return w.closeErr
+}
@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package flog_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jilleJr/flog/pkg/flog"
+	"github.com/jilleJr/flog/pkg/loglevel"
+)
+
+func TestFilterWriter_idleFlush(t *testing.T) {
+	pr, pw := io.Pipe()
+	w := flog.NewFilterWriter(pw, loglevel.Filter{MinLevel: loglevel.Error})
+	defer w.Close()
+
+	fmt.Fprintln(w, `{"level":"error","message":"Something broke"}`)
+
+	lines := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(pr).ReadString('\n')
+		lines <- line
+	}()
+	select {
+	case line := <-lines:
+		if want := `{"level":"error","message":"Something broke"}` + "\n"; line != want {
+			t.Errorf("wrong output\nwanted: %q\ngot:    %q", want, line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("log was not written before closing the writer")
+	}
+}
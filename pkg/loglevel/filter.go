@@ -18,9 +18,61 @@
 
 package loglevel
 
+import "time"
+
 type Filter struct {
 	MinLevel      Level
 	MaxLevel      Level
 	BlacklistMask Level
 	WhitelistMask Level
+
+	// Since omits logs timestamped before this time. Ignored if zero.
+	Since time.Time
+	// Before omits logs timestamped after this time. Ignored if zero.
+	Before time.Time
+}
+
+// Includes returns true if logs of the given level and timestamp should be
+// kept. Logs without a timestamp, as indicated by hasTime, are never omitted
+// by the time range.
+func (f Filter) Includes(lvl Level, t time.Time, hasTime bool) bool {
+	return f.IncludesLevel(lvl) && (!hasTime || f.IncludesTime(t))
+}
+
+// IncludesLevel returns true if logs of the given level should be kept.
+func (f Filter) IncludesLevel(lvl Level) bool {
+	if f.WhitelistMask != Undefined && f.WhitelistMask&lvl == Undefined {
+		return false
+	}
+
+	if lvl != Unknown && lvl != Undefined {
+		if f.MinLevel != Undefined && lvl < f.MinLevel {
+			return false
+		}
+
+		if f.MaxLevel != Undefined && lvl > f.MaxLevel {
+			return false
+		}
+	} else if f.BlacklistMask&Unknown > 0 {
+		return false
+	}
+
+	if f.BlacklistMask&lvl != Undefined {
+		return false
+	}
+
+	return true
+}
+
+// IncludesTime returns true if logs with the given timestamp should be kept.
+func (f Filter) IncludesTime(t time.Time) bool {
+	if !f.Since.IsZero() && t.Before(f.Since) {
+		return false
+	}
+
+	if !f.Before.IsZero() && t.After(f.Before) {
+		return false
+	}
+
+	return true
 }
@@ -47,6 +47,19 @@ var singularLevels = []Level{
 	Panic,
 }
 
+// PrintableLevels are the levels, from least to most severe, that are
+// counted separately in histograms and toggled separately in the viewer.
+var PrintableLevels = []Level{
+	Trace,
+	Debug,
+	Information,
+	Warning,
+	Error,
+	Critical,
+	Fatal,
+	Panic,
+}
+
 func (lvl Level) String() string {
 	return lvl.StringDelim('|')
 }
@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package loglevel
+
+import "strings"
+
+// NumericScheme is a scheme for mapping numeric log levels onto levels.
+type NumericScheme int
+
+const (
+	// SchemeAuto detects the scheme from the value, but only when the value
+	// is used by a single scheme: 0 is a syslog severity, 8, 9, 11 to 19,
+	// and 21 to 24 are OpenTelemetry severity numbers, and 25 and up are
+	// bunyan levels. Values 1 to 7, 10, and 20 are used by more than one
+	// scheme, and are read using the scheme detected from the other logs in
+	// the same stream, or as Unknown if none is detected.
+	SchemeAuto NumericScheme = iota
+	// SchemeBunyan is used by bunyan and pino, where 10 is trace, 20 is
+	// debug, 30 is info, 40 is warn, 50 is error, and 60 is fatal.
+	SchemeBunyan
+	// SchemeOTel is the OpenTelemetry SeverityNumber, from 1 to 24, where
+	// each level spans 4 numbers, starting with trace.
+	SchemeOTel
+	// SchemeSyslog is the syslog severity, from 0 (emerg) to 7 (debug).
+	SchemeSyslog
+)
+
+func (s NumericScheme) String() string {
+	switch s {
+	case SchemeAuto:
+		return "auto"
+	case SchemeBunyan:
+		return "bunyan"
+	case SchemeOTel:
+		return "otel"
+	case SchemeSyslog:
+		return "syslog"
+	}
+	return "unknown"
+}
+
+// ParseNumericScheme parses a scheme name, such as "bunyan" or "otel".
+func ParseNumericScheme(s string) (NumericScheme, bool) {
+	switch strings.ToLower(s) {
+	case "auto":
+		return SchemeAuto, true
+	case "bunyan", "pino":
+		return SchemeBunyan, true
+	case "otel", "opentelemetry":
+		return SchemeOTel, true
+	case "syslog":
+		return SchemeSyslog, true
+	}
+	return SchemeAuto, false
+}
+
+// DetectNumericScheme returns the only scheme that uses the value, as
+// described by SchemeAuto. Returns false if the value is used by more than
+// one scheme, or by none.
+func DetectNumericScheme(n float64) (NumericScheme, bool) {
+	switch {
+	case n < 0:
+		return SchemeAuto, false
+	case n == 0:
+		return SchemeSyslog, true
+	case n <= 7, n == 10, n == 20:
+		return SchemeAuto, false
+	case n < 25:
+		return SchemeOTel, true
+	default:
+		return SchemeBunyan, true
+	}
+}
+
+var syslogLevels = []Level{
+	Panic,       // 0 emerg
+	Fatal,       // 1 alert
+	Critical,    // 2 crit
+	Error,       // 3 err
+	Warning,     // 4 warning
+	Information, // 5 notice
+	Information, // 6 info
+	Debug,       // 7 debug
+}
+
+var otelLevels = []Level{
+	Trace,       // 1-4 TRACE
+	Debug,       // 5-8 DEBUG
+	Information, // 9-12 INFO
+	Warning,     // 13-16 WARN
+	Error,       // 17-20 ERROR
+	Fatal,       // 21-24 FATAL
+}
+
+// ParseNumericLevel maps a numeric log level onto a level using the given
+// scheme. Returns Unknown if the value is out of range for the scheme, or
+// if the scheme is SchemeAuto and the value is ambiguous.
+func ParseNumericLevel(n float64, scheme NumericScheme) Level {
+	if scheme == SchemeAuto {
+		detected, ok := DetectNumericScheme(n)
+		if !ok {
+			return Unknown
+		}
+		scheme = detected
+	}
+	switch scheme {
+	case SchemeBunyan:
+		switch {
+		case n < 0:
+			return Unknown
+		case n < 20:
+			return Trace
+		case n < 30:
+			return Debug
+		case n < 40:
+			return Information
+		case n < 50:
+			return Warning
+		case n < 60:
+			return Error
+		default:
+			return Fatal
+		}
+	case SchemeOTel:
+		if n < 1 || n > 24 || n != float64(int(n)) {
+			return Unknown
+		}
+		return otelLevels[(int(n)-1)/4]
+	case SchemeSyslog:
+		if n < 0 || n > 7 || n != float64(int(n)) {
+			return Unknown
+		}
+		return syslogLevels[int(n)]
+	}
+	return Unknown
+}
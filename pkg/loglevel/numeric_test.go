@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package loglevel
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseNumericLevel(t *testing.T) {
+	var testCases = []struct {
+		input  float64
+		scheme NumericScheme
+		want   Level
+	}{
+		{input: 30, scheme: SchemeBunyan, want: Information},
+		{input: 35, scheme: SchemeBunyan, want: Information},
+		{input: 60, scheme: SchemeBunyan, want: Fatal},
+		{input: 1, scheme: SchemeOTel, want: Trace},
+		{input: 9, scheme: SchemeOTel, want: Information},
+		{input: 24, scheme: SchemeOTel, want: Fatal},
+		{input: 25, scheme: SchemeOTel, want: Unknown},
+		{input: 0, scheme: SchemeSyslog, want: Panic},
+		{input: 5, scheme: SchemeSyslog, want: Information},
+		{input: 8, scheme: SchemeSyslog, want: Unknown},
+		{input: 0, scheme: SchemeAuto, want: Panic},
+		{input: 3, scheme: SchemeAuto, want: Unknown},
+		{input: 5, scheme: SchemeAuto, want: Unknown},
+		{input: 10, scheme: SchemeAuto, want: Unknown},
+		{input: 20, scheme: SchemeAuto, want: Unknown},
+		{input: 13, scheme: SchemeAuto, want: Warning},
+		{input: 30, scheme: SchemeAuto, want: Information},
+		{input: 50, scheme: SchemeAuto, want: Error},
+		{input: -1, scheme: SchemeAuto, want: Unknown},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%s/%v", tc.scheme, tc.input), func(t *testing.T) {
+			got := ParseNumericLevel(tc.input, tc.scheme)
+			if got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
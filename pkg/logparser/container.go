@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package logparser
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/guregu/null.v3"
+)
+
+// inputLine is a line read from the input. Lines written by a container
+// runtime are unwrapped, so that only their payload is parsed.
+type inputLine struct {
+	text       string
+	lineNumber int
+	// rawLines is the number of lines read from the input, which is more
+	// than 1 when partial lines have been joined.
+	rawLines int
+
+	// The below are only set for unwrapped container logs.
+	timestamp null.Time
+	stream    string
+	partial   bool
+}
+
+// applyTo sets the timestamp and stream from the container runtime on the
+// parsed log, unless the payload had a timestamp or stream of its own.
+func (in inputLine) applyTo(log *ParsedLog) {
+	if in.stream == "" {
+		return
+	}
+	if !log.Timestamp.Valid {
+		log.Timestamp = in.timestamp
+	}
+	if log.Fields == nil {
+		log.Fields = map[string]any{}
+	}
+	if _, ok := log.Fields["stream"]; !ok {
+		log.Fields["stream"] = in.stream
+	}
+}
+
+// criRegex matches the CRI log format, as written by containerd and CRI-O
+// to the files in /var/log/containers. The tag is "F" for full lines and
+// "P" for partial lines, optionally followed by more tags separated by ":".
+var criRegex = regexp.MustCompile(`^(\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d(?:\.\d+)?(?:Z|[+-]\d\d:\d\d)) (stdout|stderr) ([PF])(?::\S*)?(?: (.*))?$`)
+
+// dockerJSONLine is a line written by the Docker json-file logging driver.
+type dockerJSONLine struct {
+	Log    *string `json:"log"`
+	Stream string  `json:"stream"`
+	Time   string  `json:"time"`
+}
+
+// unwrapContainerLine unwraps a line written in the CRI log format, such as:
+//
+//	2021-06-18T14:50:00.123Z stdout F Sample
+//
+// or by the Docker json-file logging driver, such as:
+//
+//	{"log":"Sample\n","stream":"stderr","time":"2021-06-18T14:50:00.123Z"}
+func unwrapContainerLine(line string) (inputLine, bool) {
+	if strings.HasPrefix(line, `{"log":`) {
+		return unwrapDockerJSONLine(line)
+	}
+	return unwrapCRILine(line)
+}
+
+func unwrapCRILine(line string) (inputLine, bool) {
+	m := criRegex.FindStringSubmatch(line)
+	if m == nil {
+		return inputLine{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, m[1])
+	if err != nil {
+		return inputLine{}, false
+	}
+	return inputLine{
+		text:      m[4],
+		timestamp: null.TimeFrom(t),
+		stream:    m[2],
+		partial:   m[3] == "P",
+	}, true
+}
+
+func unwrapDockerJSONLine(line string) (inputLine, bool) {
+	var obj dockerJSONLine
+	if json.Unmarshal([]byte(line), &obj) != nil || obj.Log == nil || obj.Stream == "" {
+		return inputLine{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, obj.Time)
+	if err != nil {
+		return inputLine{}, false
+	}
+	// Docker splits long lines into partial lines, where only the last one
+	// ends with a newline.
+	text, partial := *obj.Log, true
+	if strings.HasSuffix(text, "\n") {
+		text = strings.TrimSuffix(strings.TrimSuffix(text, "\n"), "\r")
+		partial = false
+	}
+	return inputLine{
+		text:      text,
+		timestamp: null.TimeFrom(t),
+		stream:    obj.Stream,
+		partial:   partial,
+	}, true
+}
@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package logparser
+
+import (
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/apex/log"
+)
+
+// followPollInterval is how often a followed file is checked for new data
+// after reaching the end of the file.
+const followPollInterval = 250 * time.Millisecond
+
+// FollowReader reads logs from a file, and keeps waiting for new logs after
+// reaching the end of the file, similar to "tail -F". If the file is
+// truncated, or renamed and recreated by a log rotation, then it is
+// reopened.
+type FollowReader struct {
+	*StreamReader
+	file *followFile
+}
+
+// NewFollowReader opens a file to follow. Incomplete multiline logs are
+// completed after no new lines have been written for the idle duration.
+func NewFollowReader(path string, idle time.Duration) (*FollowReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	f := &followFile{
+		path:   path,
+		file:   file,
+		closed: make(chan struct{}),
+	}
+	return &FollowReader{
+		StreamReader: NewStreamReader(f, idle),
+		file:         f,
+	}, nil
+}
+
+// Close stops following the file.
+func (r *FollowReader) Close() error {
+	close(r.file.closed)
+	return nil
+}
+
+type followFile struct {
+	path   string
+	file   *os.File
+	offset int64
+	closed chan struct{}
+	// rotated is the new file at the path, which is switched to after the
+	// old file has been read to the end, as it may still be written to
+	// right after the rotation.
+	rotated *os.File
+}
+
+func (f *followFile) Read(b []byte) (int, error) {
+	for {
+		n, err := f.file.Read(b)
+		f.offset += int64(n)
+		if n > 0 {
+			return n, nil
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return 0, err
+		}
+		if f.rotated != nil {
+			f.file.Close()
+			f.file = f.rotated
+			f.rotated = nil
+			f.offset = 0
+			log.Debugf("Reopened rotated file: %s", f.path)
+			continue
+		}
+		reopened, err := f.checkRotation()
+		if err != nil {
+			return 0, err
+		}
+		if reopened {
+			continue
+		}
+		select {
+		case <-f.closed:
+			f.file.Close()
+			if f.rotated != nil {
+				f.rotated.Close()
+			}
+			return 0, io.EOF
+		case <-time.After(followPollInterval):
+		}
+	}
+}
+
+// checkRotation reopens the file if it has been truncated, or opens the new
+// file if it has been replaced by a new file at the same path. Returns true
+// if there may be more to read.
+func (f *followFile) checkRotation() (bool, error) {
+	info, err := os.Stat(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		// Rotated, but the new file has not been created yet
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	current, err := f.file.Stat()
+	if err != nil {
+		return false, err
+	}
+	if !os.SameFile(info, current) {
+		file, err := os.Open(f.path)
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		f.rotated = file
+		return true, nil
+	}
+	if info.Size() < f.offset {
+		if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+			return false, err
+		}
+		f.offset = 0
+		log.Debugf("Reopened truncated file: %s", f.path)
+		return true, nil
+	}
+	return false, nil
+}
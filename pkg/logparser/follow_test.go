@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package logparser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFollowReader_rotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	writeFile(t, path, "info: Program[0]\n      before rotation\n")
+
+	r, err := NewFollowReader(path, 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	assertNextMessage(t, r, "before rotation")
+
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, path, "info: Program[0]\n      after rotation\n")
+	assertNextMessage(t, r, "after rotation")
+
+	writeFile(t, path, "info: Program[0]\n      truncated\n")
+	assertNextMessage(t, r, "truncated")
+}
+
+func TestFollowFile_readsOldFileAfterRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	writeFile(t, path, "before rotation\n")
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &followFile{path: path, file: file, closed: make(chan struct{})}
+	defer close(f.closed)
+	assertRead(t, f, "before rotation\n")
+
+	// Rotated right after reading to the end, and then the old file is
+	// written to before the rotation is detected
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, path, "after rotation\n")
+	if _, err := f.checkRotation(); err != nil {
+		t.Fatal(err)
+	}
+	old, err := os.OpenFile(path+".1", os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old.WriteString("written to old file\n")
+	old.Close()
+
+	assertRead(t, f, "written to old file\n")
+	assertRead(t, f, "after rotation\n")
+}
+
+func assertRead(t *testing.T, f *followFile, want string) {
+	t.Helper()
+	b := make([]byte, 256)
+	n, err := f.Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b[:n]); got != want {
+		t.Errorf("wrong content\nwanted: %q\ngot:    %q", want, got)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertNextMessage(t *testing.T, r *FollowReader, want string) {
+	t.Helper()
+	done := make(chan bool)
+	go func() {
+		done <- r.Scan()
+	}()
+	select {
+	case ok := <-done:
+		if !ok {
+			t.Fatalf("unexpected end of logs, wanted message: %q", want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for message: %q", want)
+	}
+	if got := r.ParsedLog().Message; got != want {
+		t.Errorf("wrong message\nwanted: %q\ngot:    %q", want, got)
+	}
+}
@@ -21,13 +21,13 @@ package logparser
 import (
 	"bufio"
 	"io"
-
-	"github.com/jilleJr/flog/pkg/loglevel"
 )
 
 type IOReader struct {
 	scanner *bufio.Scanner
+	records recordBuilder
 	lastLog ParsedLog
+	eof     bool
 }
 
 func NewIOReader(r io.Reader) IOReader {
@@ -36,18 +36,33 @@ func NewIOReader(r io.Reader) IOReader {
 	}
 }
 
+// ForceParser disables log format detection and only uses the given parser.
+func (p *IOReader) ForceParser(parser Parser) {
+	p.records.forceParser(parser)
+}
+
 func (p *IOReader) ParsedLog() ParsedLog {
 	return p.lastLog
 }
 
+func (p *IOReader) Err() error {
+	return p.scanner.Err()
+}
+
 func (p *IOReader) Scan() bool {
-	if !p.scanner.Scan() {
-		return false
-	}
-	lastLevel := p.lastLog.Level
-	p.lastLog = ParseUsingAnyParser(p.scanner.Text())
-	if p.lastLog.Level == loglevel.Undefined || p.lastLog.Level == loglevel.Unknown {
-		p.lastLog.Level = lastLevel
+	for {
+		if log, ok := p.records.next(); ok {
+			p.lastLog = log
+			return true
+		}
+		if p.eof {
+			return false
+		}
+		if p.scanner.Scan() {
+			p.records.add(p.scanner.Text())
+		} else {
+			p.eof = true
+			p.records.flush()
+		}
 	}
-	return true
 }
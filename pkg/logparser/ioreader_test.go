@@ -0,0 +1,325 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package logparser
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jilleJr/flog/pkg/loglevel"
+)
+
+func TestIOReader_multiline(t *testing.T) {
+	input := `info: Program[0]
+      Starting up
+fail: Program[0]
+      System.Exception: Something broke
+         at Program.Main(String[] args) in Program.cs:line 12
+warn: Program[0]
+      Almost done`
+
+	want := []struct {
+		level   loglevel.Level
+		lines   int
+		message string
+	}{
+		{level: loglevel.Information, lines: 2, message: "Starting up"},
+		{level: loglevel.Error, lines: 3, message: "System.Exception: Something broke\nat Program.Main(String[] args) in Program.cs:line 12"},
+		{level: loglevel.Warning, lines: 2, message: "Almost done"},
+	}
+
+	r := NewIOReader(strings.NewReader(input))
+	var got []ParsedLog
+	for r.Scan() {
+		got = append(got, r.ParsedLog())
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("wrong number of records\nwanted: %d\ngot:    %d", len(want), len(got))
+	}
+	for i, w := range want {
+		if got[i].Level != w.level {
+			t.Errorf("record %d: wrong log level\nwanted: %s\ngot:    %s", i, w.level, got[i].Level)
+		}
+		if len(got[i].Lines) != w.lines {
+			t.Errorf("record %d: wrong number of lines\nwanted: %d\ngot:    %d", i, w.lines, len(got[i].Lines))
+		}
+		if got[i].Message != w.message {
+			t.Errorf("record %d: wrong message\nwanted: %q\ngot:    %q", i, w.message, got[i].Message)
+		}
+		if got[i].String != strings.Join(got[i].Lines, "\n") {
+			t.Errorf("record %d: string does not match lines: %q", i, got[i].String)
+		}
+	}
+}
+
+func TestIOReader_jsonDoesNotContinue(t *testing.T) {
+	input := `{"level":"error","message":"foo"}
+plain text line
+another plain text line`
+
+	r := NewIOReader(strings.NewReader(input))
+	var count int
+	for r.Scan() {
+		count++
+		if lvl := r.ParsedLog().Level; lvl != loglevel.Error {
+			t.Errorf("record %d: wrong log level\nwanted: %s\ngot:    %s", count, loglevel.Error, lvl)
+		}
+	}
+	if count != 3 {
+		t.Errorf("wrong number of records\nwanted: %d\ngot:    %d", 3, count)
+	}
+}
+
+func TestIOReader_detectFormat(t *testing.T) {
+	input := `INFO[0000] Starting up
+INFO[0000] Still starting up
+ERRO[0000] Something went wrong
+panic: something went wrong
+
+goroutine 1 [running]:
+main.main()
+	/app/main.go:12 +0x1d
+INFO[0000] Restarting`
+
+	r := NewIOReader(strings.NewReader(input))
+	var got []ParsedLog
+	for r.Scan() {
+		got = append(got, r.ParsedLog())
+	}
+
+	if len(got) != 4 {
+		t.Fatalf("wrong number of records\nwanted: %d\ngot:    %d", 4, len(got))
+	}
+	if got[2].Level != loglevel.Error || len(got[2].Lines) != 6 {
+		t.Errorf("wrong panic record\nwanted: %s with %d lines\ngot:    %s with %d lines", loglevel.Error, 6, got[2].Level, len(got[2].Lines))
+	}
+	for i, log := range got {
+		if log.Parser != "logrus-text" {
+			t.Errorf("record %d: wrong parser\nwanted: %q\ngot:    %q", i, "logrus-text", log.Parser)
+		}
+	}
+}
+
+func TestIOReader_forceParser(t *testing.T) {
+	input := `{"level":"error","message":"foo"}
+2021-01-31 17:33:54.3326|TRACE|Program|Sample`
+
+	parser, ok := FindParser("nlog")
+	if !ok {
+		t.Fatal("parser not found: nlog")
+	}
+	r := NewIOReader(strings.NewReader(input))
+	r.ForceParser(parser)
+	var got []ParsedLog
+	for r.Scan() {
+		got = append(got, r.ParsedLog())
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("wrong number of records\nwanted: %d\ngot:    %d", 2, len(got))
+	}
+	if got[0].Parser != "" || got[0].Level != loglevel.Undefined {
+		t.Errorf("JSON line should not be parsed, got parser %q and level %s", got[0].Parser, got[0].Level)
+	}
+	if got[1].Parser != "nlog" || got[1].Level != loglevel.Trace {
+		t.Errorf("wrong nlog record, got parser %q and level %s", got[1].Parser, got[1].Level)
+	}
+}
+
+func TestIOReader_cri(t *testing.T) {
+	input := `2021-06-18T14:50:00.123Z stdout F {"level":"info","message":"starting"}
+2021-06-18T14:50:01Z stderr P {"level":"error","mess
+2021-06-18T14:50:01.1Z stdout F {"level":"debug","message":"interleaved"}
+2021-06-18T14:50:01.2Z stderr F age":"boom"}`
+
+	want := []struct {
+		level   loglevel.Level
+		time    time.Time
+		stream  string
+		message string
+		line    int
+	}{
+		{level: loglevel.Information, time: time.Date(2021, 6, 18, 14, 50, 0, 123000000, time.UTC), stream: "stdout", message: "starting", line: 1},
+		{level: loglevel.Debug, time: time.Date(2021, 6, 18, 14, 50, 1, 100000000, time.UTC), stream: "stdout", message: "interleaved", line: 3},
+		{level: loglevel.Error, time: time.Date(2021, 6, 18, 14, 50, 1, 0, time.UTC), stream: "stderr", message: "boom", line: 2},
+	}
+
+	r := NewIOReader(strings.NewReader(input))
+	var got []ParsedLog
+	for r.Scan() {
+		got = append(got, r.ParsedLog())
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("wrong number of records\nwanted: %d\ngot:    %d", len(want), len(got))
+	}
+	for i, w := range want {
+		if got[i].Level != w.level {
+			t.Errorf("record %d: wrong log level\nwanted: %s\ngot:    %s", i, w.level, got[i].Level)
+		}
+		if !got[i].Timestamp.Time.Equal(w.time) {
+			t.Errorf("record %d: wrong time\nwanted: %s\ngot:    %s", i, w.time, got[i].Timestamp.Time)
+		}
+		if got[i].Fields["stream"] != w.stream {
+			t.Errorf("record %d: wrong stream\nwanted: %q\ngot:    %v", i, w.stream, got[i].Fields["stream"])
+		}
+		if got[i].Message != w.message {
+			t.Errorf("record %d: wrong message\nwanted: %q\ngot:    %q", i, w.message, got[i].Message)
+		}
+		if got[i].LineNumber != w.line {
+			t.Errorf("record %d: wrong line number\nwanted: %d\ngot:    %d", i, w.line, got[i].LineNumber)
+		}
+	}
+}
+
+func TestIOReader_dockerJSON(t *testing.T) {
+	input := `{"log":"info: Program[0]\n","stream":"stdout","time":"2021-06-18T14:50:00.123Z"}
+{"log":"      Starting up\n","stream":"stdout","time":"2021-06-18T14:50:00.124Z"}
+{"log":"fail: Program[0]\n","stream":"stderr","time":"2021-06-18T14:50:01Z"}
+{"log":"      System.Exception: Some","stream":"stderr","time":"2021-06-18T14:50:01Z"}
+{"log":"thing broke\n","stream":"stderr","time":"2021-06-18T14:50:01Z"}`
+
+	want := []struct {
+		level  loglevel.Level
+		stream string
+		text   string
+	}{
+		{level: loglevel.Information, stream: "stdout", text: "info: Program[0]\n      Starting up"},
+		{level: loglevel.Error, stream: "stderr", text: "fail: Program[0]\n      System.Exception: Something broke"},
+	}
+
+	r := NewIOReader(strings.NewReader(input))
+	var got []ParsedLog
+	for r.Scan() {
+		got = append(got, r.ParsedLog())
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("wrong number of records\nwanted: %d\ngot:    %d", len(want), len(got))
+	}
+	for i, w := range want {
+		if got[i].Level != w.level {
+			t.Errorf("record %d: wrong log level\nwanted: %s\ngot:    %s", i, w.level, got[i].Level)
+		}
+		if got[i].Parser != "dotnet" {
+			t.Errorf("record %d: wrong parser\nwanted: %q\ngot:    %q", i, "dotnet", got[i].Parser)
+		}
+		if got[i].Fields["stream"] != w.stream {
+			t.Errorf("record %d: wrong stream\nwanted: %q\ngot:    %v", i, w.stream, got[i].Fields["stream"])
+		}
+		if got[i].String != w.text {
+			t.Errorf("record %d: wrong text\nwanted: %q\ngot:    %q", i, w.text, got[i].String)
+		}
+	}
+}
+
+func TestIOReader_detectSyslog(t *testing.T) {
+	input := `Oct 11 22:14:15 myhost kernel: [12346.678902] Linux version 5.15.0-86-generic (buildd@lcy02-amd64-025)
+Oct 11 22:14:15 myhost kernel: [12347.678903] Command line: BOOT_IMAGE=/boot/vmlinuz-5.15.0-86-generic ro quiet splash
+Oct 11 22:14:15 myhost kernel: [12348.678904] KERNEL supported cpus:
+Oct 11 22:14:15 myhost kernel: [12349.678905]   Intel GenuineIntel
+Oct 11 22:14:15 myhost kernel: [12350.678906]   AMD AuthenticAMD
+Oct 11 22:14:15 myhost kernel: [12351.678907] BIOS-provided physical RAM map:
+Oct 11 22:14:15 myhost kernel: [12352.678908] BIOS-e820: [mem 0x0000000000000000-0x000000000009efff] usable
+Oct 11 22:14:15 myhost kernel: [12353.678909] NX (Execute Disable) protection: active
+Oct 11 22:14:15 myhost kernel: [12354.678910] SMBIOS 3.2.0 present.
+Oct 11 22:14:15 myhost kernel: [12355.678911] tsc: Detected 2400.000 MHz processor
+Oct 11 22:14:15 myhost kernel: [12356.678912] e820: update [mem 0x00000000-0x00000fff] usable ==> reserved
+Oct 11 22:14:15 myhost kernel: [12357.678913] last_pfn = 0x47f000 max_arch_pfn = 0x400000000
+Oct 11 22:14:15 myhost kernel: [12358.678914] x86/PAT: Configuration [0-7]: WB  WC  UC- UC  WB  WP  UC- WT
+Oct 11 22:14:15 myhost kernel: [12359.678915] Using GB pages for direct mapping
+Oct 11 22:14:15 myhost kernel: [12360.678916] RAMDISK: [mem 0x2f2c0000-0x33637fff]
+Oct 11 22:14:15 myhost kernel: [12361.678917] ACPI: Early table checksum verification disabled
+Oct 11 22:14:15 myhost kernel: [12362.678918] ACPI: RSDP 0x00000000000F05B0 000024 (v02 ALASKA)
+Oct 11 22:14:15 myhost kernel: [12363.678919] No NUMA configuration found
+Oct 11 22:14:15 myhost kernel: [12364.678920] Zone ranges:
+Oct 11 22:14:15 myhost kernel: [12365.678921] usb 1-1: new high-speed USB device number 2 using xhci_hcd
+Oct 11 22:14:16 myhost systemd[1]: Started Session 1 of user root.
+Oct 11 22:14:17 myhost sshd[22]: Accepted publickey for root from 10.0.0.2 port 51234 ssh2`
+
+	r := NewIOReader(strings.NewReader(input))
+	var got []ParsedLog
+	for r.Scan() {
+		got = append(got, r.ParsedLog())
+	}
+
+	if len(got) != 22 {
+		t.Fatalf("wrong number of records\nwanted: %d\ngot:    %d", 22, len(got))
+	}
+	for i, log := range got {
+		if log.Parser != "syslog-rfc3164" {
+			t.Errorf("record %d: wrong parser\nwanted: %q\ngot:    %q", i, "syslog-rfc3164", log.Parser)
+		}
+		if log.Level != loglevel.Undefined {
+			t.Errorf("record %d: wrong log level\nwanted: %s\ngot:    %s", i, loglevel.Undefined, log.Level)
+		}
+	}
+	if app := got[21].Fields["appname"]; app != "sshd" {
+		t.Errorf("wrong app name\nwanted: %q\ngot:    %v", "sshd", app)
+	}
+}
+
+func TestIOReader_numericLevelScheme(t *testing.T) {
+	var testCases = []struct {
+		name  string
+		input string
+		want  []loglevel.Level
+	}{
+		{
+			name: "pino",
+			input: `{"level":30,"msg":"started"}
+{"level":50,"msg":"failed"}
+{"level":20,"msg":"retrying"}
+{"level":10,"msg":"connecting"}`,
+			want: []loglevel.Level{loglevel.Information, loglevel.Error, loglevel.Debug, loglevel.Trace},
+		},
+		{
+			name: "pino detected after ambiguous",
+			input: `{"level":20,"msg":"retrying"}
+{"level":10,"msg":"connecting"}
+{"level":60,"msg":"crashed"}`,
+			want: []loglevel.Level{loglevel.Debug, loglevel.Trace, loglevel.Fatal},
+		},
+		{
+			name: "ambiguous",
+			input: `{"level":"error","msg":"failed"}
+{"level":20,"msg":"retrying"}`,
+			want: []loglevel.Level{loglevel.Error, loglevel.Unknown},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewIOReader(strings.NewReader(tc.input))
+			var got []loglevel.Level
+			for r.Scan() {
+				got = append(got, r.ParsedLog().Level)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("wrong number of records\nwanted: %d\ngot:    %d", len(tc.want), len(got))
+			}
+			for i, want := range tc.want {
+				if got[i] != want {
+					t.Errorf("record %d: wrong log level\nwanted: %s\ngot:    %s", i, want, got[i])
+				}
+			}
+		})
+	}
+}
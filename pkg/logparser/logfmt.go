@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package logparser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parseLogfmt parses key-value pairs, such as:
+//
+//	msg="A walrus appears" animal=walrus size=10
+//
+// Words without an equal sign are ignored.
+func parseLogfmt(s string) map[string]any {
+	fields := map[string]any{}
+	for {
+		s = strings.TrimLeft(s, " \t·")
+		if s == "" {
+			return fields
+		}
+		end := strings.IndexAny(s, "= \t")
+		if end == -1 {
+			return fields
+		}
+		if s[end] != '=' {
+			s = s[end:]
+			continue
+		}
+		key := s[:end]
+		s = s[end+1:]
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			value, s = readLogfmtQuoted(s)
+		} else {
+			valueEnd := strings.IndexAny(s, " \t")
+			if valueEnd == -1 {
+				valueEnd = len(s)
+			}
+			value, s = s[:valueEnd], s[valueEnd:]
+		}
+		if key != "" {
+			fields[key] = value
+		}
+	}
+}
+
+func readLogfmtQuoted(s string) (string, string) {
+	escaped := false
+	for i := 1; i < len(s); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case s[i] == '\\':
+			escaped = true
+		case s[i] == '"':
+			if value, err := strconv.Unquote(s[:i+1]); err == nil {
+				return value, s[i+1:]
+			}
+			return s[1:i], s[i+1:]
+		}
+	}
+	return s[1:], ""
+}
@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package logparser
+
+import (
+	"container/heap"
+	"time"
+)
+
+// MergeReader reads from multiple readers at once and interleaves their
+// logs in chronological order. Logs with the same timestamp are kept in the
+// order of the readers, and then in the order they were read.
+type MergeReader struct {
+	sources []mergeSource
+	queue   mergeQueue
+	lastLog ParsedLog
+	started bool
+}
+
+type mergeSource struct {
+	name   string
+	reader Reader
+}
+
+type mergeItem struct {
+	log    ParsedLog
+	time   time.Time
+	source int
+	seq    int
+}
+
+// NewMergeReader returns a reader that merges the logs from the given
+// readers. The names are set as ParsedLog.Source on the merged logs.
+func NewMergeReader(names []string, readers []Reader) *MergeReader {
+	sources := make([]mergeSource, len(readers))
+	for i, r := range readers {
+		sources[i] = mergeSource{name: names[i], reader: r}
+	}
+	return &MergeReader{sources: sources}
+}
+
+func (p *MergeReader) ParsedLog() ParsedLog {
+	return p.lastLog
+}
+
+// Err returns the first error from any of the merged readers.
+func (p *MergeReader) Err() error {
+	for _, src := range p.sources {
+		if err := src.reader.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *MergeReader) Scan() bool {
+	if !p.started {
+		p.started = true
+		for i := range p.sources {
+			p.readNext(i)
+		}
+	}
+	if p.queue.Len() == 0 {
+		return false
+	}
+	item := heap.Pop(&p.queue).(mergeItem)
+	p.lastLog = item.log
+	p.readNext(item.source)
+	return true
+}
+
+// readNext reads the next log from a source and queues it.
+func (p *MergeReader) readNext(source int) {
+	src := p.sources[source]
+	if !src.reader.Scan() {
+		return
+	}
+	log := src.reader.ParsedLog()
+	log.Source = src.name
+	item := mergeItem{
+		log:    log,
+		time:   log.Timestamp.Time,
+		source: source,
+		seq:    log.LineNumber,
+	}
+	heap.Push(&p.queue, item)
+}
+
+type mergeQueue []mergeItem
+
+func (q mergeQueue) Len() int { return len(q) }
+
+func (q mergeQueue) Less(i, j int) bool {
+	if !q[i].time.Equal(q[j].time) {
+		return q[i].time.Before(q[j].time)
+	}
+	if q[i].source != q[j].source {
+		return q[i].source < q[j].source
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q mergeQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *mergeQueue) Push(x any) { *q = append(*q, x.(mergeItem)) }
+
+func (q *mergeQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
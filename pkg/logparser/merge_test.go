@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package logparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMergeReader(t *testing.T) {
+	a := NewIOReader(strings.NewReader(`{"timestamp":"2022-01-01T10:00:00Z","message":"a1"}
+{"message":"a1 no timestamp"}
+{"timestamp":"2022-01-01T10:00:03Z","message":"a2"}`))
+	b := NewIOReader(strings.NewReader(`{"timestamp":"2022-01-01T10:00:01Z","message":"b1"}
+{"timestamp":"2022-01-01T10:00:02Z","message":"b2"}
+{"timestamp":"2022-01-01T10:00:03Z","message":"b3"}`))
+
+	r := NewMergeReader([]string{"a.log", "b.log"}, []Reader{&a, &b})
+	var got []string
+	for r.Scan() {
+		log := r.ParsedLog()
+		got = append(got, log.Source+": "+log.Message)
+	}
+
+	want := []string{
+		"a.log: a1",
+		"a.log: a1 no timestamp",
+		"b.log: b1",
+		"b.log: b2",
+		"a.log: a2",
+		"b.log: b3",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("wrong merge order\nwanted: %q\ngot:    %q", want, got)
+	}
+}
@@ -27,4 +27,29 @@ type ParsedLog struct {
 	Level     loglevel.Level
 	String    string
 	Timestamp null.Time
+	Message   string
+	// Logger is the name or category of the logger, if any.
+	Logger string
+	// Caller is the source code location that produced the log, if any.
+	Caller string
+	// Fields contains any additional structured values of the log, such as
+	// the remaining JSON properties or logfmt key-value pairs.
+	Fields map[string]any
+	// Lines contains the header line followed by any continuation lines,
+	// such as stack traces. String contains the same lines joined by
+	// newlines.
+	Lines []string
+	// Parser is the name of the parser that matched the header line, or
+	// empty if no parser matched.
+	Parser string
+	// LineNumber is the 1-based line number of the header line in the
+	// stream it was read from.
+	LineNumber int
+	// Source is the name of the file the log was read from when merging
+	// logs from multiple files, or empty otherwise.
+	Source string
+
+	// levelNumber is the numeric level when its scheme is left to be
+	// detected from the other logs in the stream.
+	levelNumber null.Float
 }
@@ -19,7 +19,9 @@ package logparser
 
 import (
 	"encoding/json"
+	"io"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -37,138 +39,400 @@ const (
 )
 
 type Parser interface {
+	// Name is a unique name of the log format, such as "json" or "klog".
+	Name() string
 	Parse(line string) (ParsedLog, ResultType)
 }
 
 func ParseUsingAnyParser(line string) ParsedLog {
+	log, _ := parseUsingAnyParser(line)
+	return log
+}
+
+func parseUsingAnyParser(line string) (ParsedLog, ResultType) {
+	for _, parser := range defaultParsers {
+		if log, result := parseUsingParser(parser, line); result != ResultNoMatch {
+			return log, result
+		}
+	}
+	return ParsedLog{String: line, Level: loglevel.Undefined}, ResultNoMatch
+}
+
+func parseUsingParser(parser Parser, line string) (ParsedLog, ResultType) {
+	log, result := parser.Parse(line)
+	if result == ResultNoMatch {
+		return ParsedLog{String: line, Level: loglevel.Undefined}, ResultNoMatch
+	}
+	log.Parser = parser.Name()
+	return log, result
+}
+
+// FindParser returns the parser with the given name.
+func FindParser(name string) (Parser, bool) {
 	for _, parser := range defaultParsers {
-		log, result := parser.Parse(line)
-		if result == ResultMatch || result == ResultMatchMayContinue {
-			return log
+		if parser.Name() == name {
+			return parser, true
 		}
 	}
-	return ParsedLog{String: line, Level: loglevel.Undefined}
+	return nil, false
 }
 
+// ReplaceParser replaces the parser with the same name, such as to
+// configure the built-in JSONParser. Returns false if no parser has that
+// name.
+func ReplaceParser(parser Parser) bool {
+	for i, p := range defaultParsers {
+		if p.Name() == parser.Name() {
+			defaultParsers[i] = parser
+			return true
+		}
+	}
+	return false
+}
+
+// ParserNames returns the names of all parsers, in the order they are tried.
+func ParserNames() []string {
+	names := make([]string, len(defaultParsers))
+	for i, parser := range defaultParsers {
+		names[i] = parser.Name()
+	}
+	return names
+}
+
+// RegExParser parses logs using a regular expression. The timestamp and
+// level are read from the groups with the indexes GroupTimestamp and
+// GroupLevel, or from the named groups "time" and "level" if no index is
+// set. Further details are read from the named groups "message", "logger",
+// "caller", and "fields", where the latter is parsed as logfmt key-value
+// pairs. Any other named groups are added as fields.
 type RegExParser struct {
+	ParserName     string
 	Expression     *regexp.Regexp
 	TimeLayout     string
 	GroupTimestamp int
 	GroupLevel     int
 }
 
+func (p RegExParser) Name() string {
+	return p.ParserName
+}
+
 func (p RegExParser) Parse(line string) (ParsedLog, ResultType) {
 	stripped := stripansi.Strip(line)
 	matches := p.Expression.FindStringSubmatch(stripped)
-	matchesLen := len(matches)
-	if matchesLen == 0 {
+	if len(matches) == 0 {
 		return ParsedLog{}, ResultNoMatch
 	}
 	log := ParsedLog{
 		String: line,
 	}
-	if p.GroupTimestamp > 0 && p.GroupTimestamp < matchesLen {
-		log.Timestamp = parseTime(matches[p.GroupTimestamp], p.TimeLayout)
+	timestampIndex := p.groupIndex(p.GroupTimestamp, "time")
+	if timestampIndex > 0 && timestampIndex < len(matches) {
+		log.Timestamp = parseTime(matches[timestampIndex], p.TimeLayout)
+		if !log.Timestamp.Valid {
+			if t, ok := parseEpoch(matches[timestampIndex]); ok {
+				log.Timestamp = null.TimeFrom(t)
+			}
+		}
 	}
-	if p.GroupLevel > 0 && p.GroupLevel < matchesLen {
-		log.Level = loglevel.ParseLevel(matches[p.GroupLevel])
+	levelIndex := p.groupIndex(p.GroupLevel, "level")
+	if levelIndex > 0 && levelIndex < len(matches) {
+		log.Level = loglevel.ParseLevel(matches[levelIndex])
 	}
-	return log, ResultMatch
+	fields := map[string]any{}
+	for i, name := range p.Expression.SubexpNames() {
+		if matches[i] == "" || i == timestampIndex || i == levelIndex {
+			continue
+		}
+		switch name {
+		case "":
+		case "message":
+			log.Message = matches[i]
+		case "logger":
+			log.Logger = matches[i]
+		case "caller":
+			log.Caller = matches[i]
+		case "fields":
+			for key, value := range parseLogfmt(matches[i]) {
+				fields[key] = value
+			}
+		default:
+			fields[name] = matches[i]
+		}
+	}
+	readWellKnownFields(&log, fields)
+	return log, ResultMatchMayContinue
+}
+
+func (p RegExParser) groupIndex(index int, name string) int {
+	if index > 0 {
+		return index
+	}
+	return p.Expression.SubexpIndex(name)
+}
+
+type JSONParser struct {
+	// LevelScheme is used to map numeric levels, such as "level":30 from
+	// bunyan, onto log levels. Defaults to detecting the scheme.
+	LevelScheme loglevel.NumericScheme
+	// LevelKey is the key path of the level, such as "log.level", to use
+	// instead of the default keys.
+	LevelKey string
+	// TimeKey is the key path of the timestamp, such as "@t", to use
+	// instead of the default keys.
+	TimeKey string
 }
 
-type JSONParser struct{}
+func (p JSONParser) Name() string {
+	return "json"
+}
 
 func (p JSONParser) Parse(line string) (ParsedLog, ResultType) {
-	var obj map[string]any
-	if json.Unmarshal([]byte(line), &obj) != nil {
+	obj, ok := decodeJSONObject(line)
+	if !ok {
 		return ParsedLog{}, ResultNoMatch
 	}
+	levelKeys, timeKeys := jsonLevelKeys, jsonTimestampKeys
+	if p.LevelKey != "" {
+		levelKeys = []string{p.LevelKey}
+	}
+	if p.TimeKey != "" {
+		timeKeys = []string{p.TimeKey}
+	}
 	log := ParsedLog{
-		Timestamp: parseTime(readJSONTimestamp(obj), ""),
-		Level:     loglevel.ParseLevel(readJSONLevel(obj)),
+		Timestamp: readJSONTimestamp(obj, timeKeys),
 		String:    line,
 	}
+	log.Level, log.levelNumber = readJSONLevel(obj, levelKeys, p.LevelScheme)
+	readWellKnownFields(&log, obj)
 	return log, ResultMatch
 }
 
-func readJSONLevel(obj map[string]any) string {
-	var msg string
-	var ok bool
-	if msg, ok = tryMapValueString(obj, "level"); ok {
-		return msg
-	} else if msg, ok = tryMapValueString(obj, "lvl"); ok {
-		return msg
-	} else if msg, ok = tryMapValueString(obj, "severity"); ok {
-		return msg
+// decodeJSONObject decodes a line that holds a single JSON object. Numbers
+// are decoded as json.Number, so that for example nanosecond Unix epochs
+// keep their precision.
+func decodeJSONObject(line string) (map[string]any, bool) {
+	d := json.NewDecoder(strings.NewReader(line))
+	d.UseNumber()
+	var obj map[string]any
+	if d.Decode(&obj) != nil || obj == nil {
+		return nil, false
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return nil, false
+	}
+	return obj, true
+}
+
+// The keys are key paths, where nested objects are separated by dots. They
+// cover the JSON logs from among others logrus, zap, zerolog, slog, pino,
+// bunyan, Serilog's compact format (CLEF), Elastic Common Schema (ECS),
+// Google Cloud Logging, and OpenTelemetry.
+var (
+	jsonLevelKeys = []string{
+		"level", "lvl", "severity", "log.level", "@l",
+		"logging.googleapis.com.severity", "logging.googleapis.com/severity",
+		"SeverityText", "severityText", "severity_text",
+		"SeverityNumber", "severityNumber", "severity_number",
+	}
+	jsonTimestampKeys = []string{"timestamp", "time", "ts", "@timestamp", "@t", "date", "datetime"}
+	messageKeys       = []string{"msg", "message", "@m", "@mt"}
+	loggerKeys        = []string{"logger", "logger_name", "category", "log.logger", "SourceContext"}
+	callerKeys        = []string{"caller", "source"}
+)
+
+// otelLevelKeys are the keys that only hold OpenTelemetry severity numbers,
+// so the scheme does not need to be detected from the value.
+var otelLevelKeys = map[string]bool{"SeverityNumber": true, "severityNumber": true, "severity_number": true}
+
+// readJSONLevel reads and removes the level, which may be either a string,
+// such as "info", or a number, such as 30, mapped using the scheme. If the
+// scheme is left to be detected, then the number is returned as well, so
+// that the scheme can be detected from the other logs in the stream.
+func readJSONLevel(obj map[string]any, keys []string, scheme loglevel.NumericScheme) (loglevel.Level, null.Float) {
+	for _, key := range keys {
+		switch value := takeMapValue(obj, key, isStringOrNumber).(type) {
+		case string:
+			return loglevel.ParseLevel(value), null.Float{}
+		case json.Number:
+			num, err := value.Float64()
+			if err != nil {
+				return loglevel.Unknown, null.Float{}
+			}
+			if scheme == loglevel.SchemeAuto && otelLevelKeys[key] {
+				scheme = loglevel.SchemeOTel
+			}
+			if scheme == loglevel.SchemeAuto {
+				return loglevel.ParseNumericLevel(num, scheme), null.FloatFrom(num)
+			}
+			return loglevel.ParseNumericLevel(num, scheme), null.Float{}
+		}
+	}
+	return loglevel.Unknown, null.Float{}
+}
+
+// readJSONTimestamp reads and removes the timestamp, which may be either a
+// string or a Unix epoch, such as "ts":1623887400.123456 from zap. Epochs
+// may also be written as strings.
+func readJSONTimestamp(obj map[string]any, keys []string) null.Time {
+	for _, key := range keys {
+		switch value := takeMapValue(obj, key, isStringOrNumber).(type) {
+		case string:
+			if t, ok := parseEpoch(value); ok {
+				return null.TimeFrom(t)
+			}
+			return parseTime(value, "")
+		case json.Number:
+			if t, ok := parseEpoch(value.String()); ok {
+				return null.TimeFrom(t)
+			}
+			return null.Time{}
+		}
+	}
+	return null.Time{}
+}
+
+// readWellKnownFields moves the message, logger, and caller from the fields
+// map over to the log, unless already set, and stores the remaining fields
+// on the log.
+func readWellKnownFields(log *ParsedLog, fields map[string]any) {
+	if msg := takeMapValueString(fields, messageKeys); log.Message == "" {
+		log.Message = msg
+	}
+	if logger := takeMapValueString(fields, loggerKeys); log.Logger == "" {
+		log.Logger = logger
+	}
+	if caller := takeMapValueString(fields, callerKeys); log.Caller == "" {
+		log.Caller = caller
+	}
+	if len(fields) > 0 {
+		log.Fields = fields
 	}
-	return ""
 }
 
-func readJSONTimestamp(obj map[string]any) string {
-	var msg string
-	var ok bool
-	if msg, ok = tryMapValueString(obj, "timestamp"); ok {
-		return msg
-	} else if msg, ok = tryMapValueString(obj, "date"); ok {
-		return msg
-	} else if msg, ok = tryMapValueString(obj, "datetime"); ok {
-		return msg
+// takeMapValueString returns the first string value found by any of the
+// key paths, and removes it from the map.
+func takeMapValueString(obj map[string]any, keys []string) string {
+	for _, key := range keys {
+		if str, ok := takeMapValue(obj, key, isString).(string); ok {
+			return str
+		}
 	}
 	return ""
 }
 
-func tryMapValueString(obj map[string]any, key string) (string, bool) {
-	if value, ok := obj[key]; ok {
-		if str, ok := value.(string); ok {
-			return str, true
+// takeMapValue returns the value found by the key path, if accepted, and
+// removes it from the map, along with any nested objects left empty.
+// Nested objects are separated by dots, such as "log.level", but keys
+// that contain dots, such as "logging.googleapis.com/severity", are also
+// matched as-is. Returns nil if no value was found.
+func takeMapValue(obj map[string]any, path string, accept func(any) bool) any {
+	if value, ok := obj[path]; ok && accept(value) {
+		delete(obj, path)
+		return value
+	}
+	for i := 0; i < len(path); i++ {
+		if path[i] != '.' {
+			continue
+		}
+		nested, ok := obj[path[:i]].(map[string]any)
+		if !ok {
+			continue
 		}
+		if value := takeMapValue(nested, path[i+1:], accept); value != nil {
+			if len(nested) == 0 {
+				delete(obj, path[:i])
+			}
+			return value
+		}
+	}
+	return nil
+}
+
+func isString(value any) bool {
+	_, ok := value.(string)
+	return ok
+}
+
+func isStringOrNumber(value any) bool {
+	switch value.(type) {
+	case string, json.Number:
+		return true
 	}
-	return "", false
+	return false
 }
 
 const dateTimeRegex = `\d{4}-\d\d?-\d\d?(?:[ ·T]\d\d?[:.]\d\d?(?:[:.]\d+(?:\.\d+)?)?(?:Z|[+-]?\d{2}:?\d{2})?)?`
 
 func compileRegexp(value string) *regexp.Regexp {
-	value = strings.ReplaceAll(value, "{date}", dateTimeRegex)
-	return regexp.MustCompile(value)
+	return regexp.MustCompile(expandRegexp(value))
+}
+
+// CompileRegexp compiles a regular expression, where the placeholder
+// "{date}" is expanded to an expression that matches most date-times.
+func CompileRegexp(value string) (*regexp.Regexp, error) {
+	return regexp.Compile(expandRegexp(value))
+}
+
+func expandRegexp(value string) string {
+	return strings.ReplaceAll(value, "{date}", dateTimeRegex)
+}
+
+// AddParser adds a parser to the list of parsers used to detect log
+// formats. Parsers with higher priority are tried first. The built-in
+// parsers have priority 0, and parsers of equal priority are tried in the
+// order they were added.
+func AddParser(parser Parser, priority int) {
+	i := len(defaultParsers)
+	for i > 0 && defaultParserPriorities[i-1] < priority {
+		i--
+	}
+	defaultParsers = append(defaultParsers[:i], append([]Parser{parser}, defaultParsers[i:]...)...)
+	defaultParserPriorities = append(defaultParserPriorities[:i], append([]int{priority}, defaultParserPriorities[i:]...)...)
 }
 
 var defaultParsers = []Parser{
 	JSONParser{},
 	RegExParser{
 		// 2021-01-31 17:33:54.3326|TRACE|Program|Sample
-		Expression:     compileRegexp(`^({date})\|(\w+)\|.*$`),
-		GroupTimestamp: 1,
-		GroupLevel:     2,
+		ParserName: "nlog",
+		Expression: compileRegexp(`^(?P<time>{date})\|(?P<level>\w+)\|(?:(?P<logger>[^|]*)\|(?P<message>.*)|.*)$`),
 	},
 	RegExParser{
 		// time="2021-01-31T19:04:01+01:00" level=trace msg="A walrus appears" animal=walrus
-		Expression:     compileRegexp(`^time="({date})"[\s·]level="?(\w+)["\s$].*$`),
-		GroupTimestamp: 1,
-		GroupLevel:     2,
+		ParserName: "logrus",
+		Expression: compileRegexp(`^time="(?P<time>{date})"[\s·]level="?(?P<level>\w+)"?(?:[\s·](?P<fields>.*))?$`),
 	},
 	RegExParser{
 		// WARN[0000] A walrus appears            animal=walrus
+		ParserName: "logrus-text",
+		Expression: compileRegexp(`^(?P<level>\w{4})\[\d+\]\s*(?P<message>.*?)(?:\s{2,}(?P<fields>\w[\w.-]*=.*))?$`),
+	},
+	RegExParser{
 		// fail: Program[0]
-		Expression: compileRegexp(`^(\w{4})[\[:].*$`),
-		GroupLevel: 1,
+		ParserName: "dotnet",
+		Expression: compileRegexp(`^(?P<level>\w{4}): (?P<logger>\S+\[\d+\])(?:\s+(?P<message>.*))?$`),
 	},
 	RegExParser{
 		// I0204 09:00:44.662471       i health.go:55] Starting MySQL health checker...
-		Expression:     compileRegexp(`^(\w)(\d{4} \d\d:\d\d:\d\d(\.?\d+)?)\s+.*$`),
-		TimeLayout:     "0102 15:04:05.999999999",
-		GroupTimestamp: 2,
-		GroupLevel:     1,
+		ParserName: "klog",
+		Expression: compileRegexp(`^(?P<level>\w)(?P<time>\d{4} \d\d:\d\d:\d\d(?:\.?\d+)?)\s+(?:(?P<thread>\S+)\s+(?P<caller>[^\s\]]+)\]\s?(?P<message>.*)|.*)$`),
+		TimeLayout: "0102 15:04:05.999999999",
 	},
+	// Before wharf-core, as its fallback otherwise matches syslog kernel
+	// logs, such as: Oct 11 22:14:15 host kernel: [12345.678] Sample
+	SyslogParser{RFC5424: true},
+	SyslogParser{},
 	RegExParser{
 		// Jun-18 14:50+0200 [DEBUG | TEST | wharf-core/main.go:23] Sample  hello=world
-		Expression:     compileRegexp(`^([a-zA-Z0-9:+ \-]+) \[(\w+).*$`),
-		TimeLayout:     "Jan-02 15:04Z0700",
-		GroupTimestamp: 1,
-		GroupLevel:     2,
+		ParserName: "wharf-core",
+		Expression: compileRegexp(`^(?P<time>[a-zA-Z0-9:+ \-]+) \[(?P<level>\w+)(?:\s*\|\s*(?P<logger>[^|\]]*?)\s*\|\s*(?P<caller>[^\]]*?)\s*\]\s*(?P<message>.*?)(?:\s{2,}(?P<fields>\w[\w.-]*=.*))?|.*)$`),
+		TimeLayout: "Jan-02 15:04Z0700",
 	},
 }
 
+var defaultParserPriorities = make([]int, len(defaultParsers))
+
 var timeLayouts = []string{
 	"2006-01-02 15:04:05.999999999Z07:00",
 	"2006-01-02 15:04:05.999999999-0700",
@@ -200,26 +464,106 @@ var timeLayouts = []string{
 	time.RubyDate, // "Mon Jan 02 15:04:05 -0700 2006"
 }
 
+// ParseTimestamp parses a timestamp using any of the time layouts that
+// the log parsers recognize. Timestamps without a date are dated today, the
+// same as in the parsed logs.
+func ParseTimestamp(value string) null.Time {
+	return parseTime(value, "")
+}
+
 func parseTime(value, preferredLayout string) null.Time {
 	if value == "" {
 		return null.Time{}
 	}
 	if preferredLayout != "" {
 		if t, err := time.Parse(preferredLayout, value); err == nil {
-			return null.TimeFrom(timeDefaults(t))
+			return null.TimeFrom(timeDefaults(t, preferredLayout))
 		}
 	}
 	for _, layout := range timeLayouts {
 		if t, err := time.Parse(layout, value); err == nil {
-			return null.TimeFrom(timeDefaults(t))
+			return null.TimeFrom(timeDefaults(t, layout))
 		}
 	}
 	return null.Time{}
 }
 
-func timeDefaults(t time.Time) time.Time {
-	if t.Year() == 0 {
-		t = time.Date(time.Now().Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+// timeDefaults dates timestamps without a date, such as "14:05:00", to
+// today, and timestamps without a year, such as "Jun 18 14:05:00", to the
+// current year.
+func timeDefaults(t time.Time, layout string) time.Time {
+	now := time.Now()
+	switch {
+	case !layoutHasDate(layout):
+		t = time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	case t.Year() == 0:
+		t = time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
 	}
 	return t
 }
+
+// layoutHasDate returns true if the time layout contains any part of a
+// date, by checking if two different dates are formatted differently.
+func layoutHasDate(layout string) bool {
+	a := time.Date(2001, 2, 3, 0, 0, 0, 0, time.UTC)
+	b := time.Date(2002, 3, 4, 0, 0, 0, 0, time.UTC)
+	return a.Format(layout) != b.Format(layout)
+}
+
+// minEpoch is the smallest Unix epoch that is accepted, in seconds (year
+// 2001), so that other numbers, such as "20210618", are not mistaken for
+// timestamps from 1970.
+const minEpoch = 1e9
+
+// parseEpoch parses a Unix epoch timestamp, such as "1623887400.123456",
+// where the unit is inferred from the magnitude: seconds up to 1e11 (year
+// 5138), then milliseconds up to 1e14, microseconds up to 1e17, and
+// nanoseconds beyond that.
+func parseEpoch(value string) (time.Time, bool) {
+	intPart, fracPart, _ := strings.Cut(value, ".")
+	if !isDigits(intPart) || (fracPart != "" && !isDigits(fracPart)) {
+		return time.Time{}, false
+	}
+	n, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil || n < minEpoch {
+		return time.Time{}, false
+	}
+	var unit time.Duration
+	switch {
+	case n < 1e11:
+		unit = time.Second
+	case n < 1e14:
+		unit = time.Millisecond
+	case n < 1e17:
+		unit = time.Microsecond
+	default:
+		unit = time.Nanosecond
+	}
+	perSecond := int64(time.Second / unit)
+	nsec := n % perSecond * int64(unit)
+	// The fraction is in the inferred unit, so for milliseconds the first
+	// 6 digits make up the nanoseconds.
+	if fracDigits := len(strconv.FormatInt(int64(unit), 10)) - 1; fracDigits > 0 && fracPart != "" {
+		if len(fracPart) > fracDigits {
+			fracPart = fracPart[:fracDigits]
+		}
+		frac, err := strconv.ParseInt(fracPart+strings.Repeat("0", fracDigits-len(fracPart)), 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		nsec += frac
+	}
+	return time.Unix(n/perSecond, nsec).UTC(), true
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
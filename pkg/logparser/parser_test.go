@@ -18,6 +18,8 @@
 package logparser
 
 import (
+	"fmt"
+	"regexp"
 	"testing"
 	"time"
 
@@ -75,6 +77,89 @@ func TestParse(t *testing.T) {
 			level: loglevel.Debug,
 			time:  null.TimeFrom(time.Date(2021, 6, 5, 23, 50, 0, 0, time.UTC)),
 		},
+		{
+			name:  "json_bunyan",
+			line:  `{"level":50,"time":"2021-06-05T23:50:00Z","msg":"foo bar"}`,
+			level: loglevel.Error,
+			time:  null.TimeFrom(time.Date(2021, 6, 5, 23, 50, 0, 0, time.UTC)),
+		},
+		{
+			name:  "json_otel",
+			line:  `{"SeverityNumber":9,"timestamp":"2021-06-05T23:50:00Z","Body":"foo bar"}`,
+			level: loglevel.Information,
+			time:  null.TimeFrom(time.Date(2021, 6, 5, 23, 50, 0, 0, time.UTC)),
+		},
+		{
+			name:  "json_ecs",
+			line:  `{"@timestamp":"2021-06-05T23:50:00Z","log.level":"warn","message":"foo bar"}`,
+			level: loglevel.Warning,
+			time:  null.TimeFrom(time.Date(2021, 6, 5, 23, 50, 0, 0, time.UTC)),
+		},
+		{
+			name:  "json_ecs_nested",
+			line:  `{"@timestamp":"2021-06-05T23:50:00Z","log":{"level":"warn"},"message":"foo bar"}`,
+			level: loglevel.Warning,
+			time:  null.TimeFrom(time.Date(2021, 6, 5, 23, 50, 0, 0, time.UTC)),
+		},
+		{
+			name:  "json_serilog_clef",
+			line:  `{"@t":"2021-06-05T23:50:00Z","@l":"Error","@mt":"foo {Bar}","Bar":"bar"}`,
+			level: loglevel.Error,
+			time:  null.TimeFrom(time.Date(2021, 6, 5, 23, 50, 0, 0, time.UTC)),
+		},
+		{
+			name:  "json_gcp",
+			line:  `{"logging.googleapis.com":{"severity":"ERROR"},"message":"foo bar"}`,
+			level: loglevel.Error,
+		},
+		{
+			name:  "json_zap_epoch",
+			line:  `{"level":"info","ts":1623887400.123456,"msg":"foo bar"}`,
+			level: loglevel.Information,
+			time:  null.TimeFrom(time.Date(2021, 6, 16, 23, 50, 0, 123456000, time.UTC)),
+		},
+		{
+			name:  "json_epoch_millis",
+			line:  `{"level":"info","time":"1623887400123","msg":"foo bar"}`,
+			level: loglevel.Information,
+			time:  null.TimeFrom(time.Date(2021, 6, 16, 23, 50, 0, 123000000, time.UTC)),
+		},
+		{
+			name:  "json_ambiguous_number",
+			line:  `{"level":4,"timestamp":"2021-06-05T23:50:00Z","short_message":"foo bar"}`,
+			level: loglevel.Unknown,
+			time:  null.TimeFrom(time.Date(2021, 6, 5, 23, 50, 0, 0, time.UTC)),
+		},
+		{
+			name:  "syslog-rfc3164",
+			line:  `<13>Oct 11 22:14:15 myhost myapp[123]: Sample`,
+			level: loglevel.Information,
+			time:  null.TimeFrom(time.Date(time.Now().Year(), 10, 11, 22, 14, 15, 0, time.UTC)),
+		},
+		{
+			name:  "syslog-rfc3164_crit",
+			line:  `<34>Oct 11 22:14:15 myhost su: 'su root' failed for lonvick on /dev/pts/8`,
+			level: loglevel.Critical,
+			time:  null.TimeFrom(time.Date(time.Now().Year(), 10, 11, 22, 14, 15, 0, time.UTC)),
+		},
+		{
+			name:  "syslog-rfc3164_no_pri",
+			line:  `Oct  1 08:00:00 myhost systemd[1]: Started Session 1 of user root.`,
+			level: loglevel.Undefined,
+			time:  null.TimeFrom(time.Date(time.Now().Year(), 10, 1, 8, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:  "syslog-rfc5424",
+			line:  `<165>1 2003-10-11T22:14:15.003Z myhost myapp - ID47 [exampleSDID@32473 iut="3"] Sample`,
+			level: loglevel.Information,
+			time:  null.TimeFrom(time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC)),
+		},
+		{
+			name:  "syslog-rfc5424_emerg",
+			line:  `<0>1 2003-10-11T22:14:15Z myhost kernel - - - Kernel panic`,
+			level: loglevel.Panic,
+			time:  null.TimeFrom(time.Date(2003, 10, 11, 22, 14, 15, 0, time.UTC)),
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -90,6 +175,120 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestParse_details(t *testing.T) {
+	testCases := []struct {
+		name    string
+		line    string
+		message string
+		logger  string
+		caller  string
+		fields  map[string]any
+	}{
+		{
+			name:    "nlog",
+			line:    `2021-01-31 17:33:54.3326|TRACE|Program|Sample`,
+			message: "Sample",
+			logger:  "Program",
+		},
+		{
+			name:    "logrus",
+			line:    `time="2021-01-31T19:04:01+01:00" level=info msg="A walrus appears" animal=walrus`,
+			message: "A walrus appears",
+			fields:  map[string]any{"animal": "walrus"},
+		},
+		{
+			name:    "logrus_text",
+			line:    `WARN[0000] A walrus appears                              animal=walrus size="very big"`,
+			message: "A walrus appears",
+			fields:  map[string]any{"animal": "walrus", "size": "very big"},
+		},
+		{
+			name:   "dotnet",
+			line:   `fail: Program[0]`,
+			logger: "Program[0]",
+		},
+		{
+			name:    "klog",
+			line:    `I0204 09:00:44.662471       i health.go:55] Starting MySQL health checker...`,
+			message: "Starting MySQL health checker...",
+			caller:  "health.go:55",
+			fields:  map[string]any{"thread": "i"},
+		},
+		{
+			name:    "iver-wharf/wharf-core",
+			line:    `Jun-18 14:50+0200 [DEBUG | TEST | wharf-core/main.go:23] Sample  hello=world`,
+			message: "Sample",
+			logger:  "TEST",
+			caller:  "wharf-core/main.go:23",
+			fields:  map[string]any{"hello": "world"},
+		},
+		{
+			name:    "json",
+			line:    `{"level":"debug","timestamp":"2021-06-05T23:50:00Z","message":"foo bar","logger":"main","caller":"main.go:12","user":"walrus"}`,
+			message: "foo bar",
+			logger:  "main",
+			caller:  "main.go:12",
+			fields:  map[string]any{"user": "walrus"},
+		},
+		{
+			name:    "json_ecs_nested",
+			line:    `{"@timestamp":"2021-06-05T23:50:00Z","log":{"level":"info","logger":"main","origin":{"file":{"line":12}}},"message":"foo bar"}`,
+			message: "foo bar",
+			logger:  "main",
+			fields:  map[string]any{"log": map[string]any{"origin": map[string]any{"file": map[string]any{"line": 12}}}},
+		},
+		{
+			name:    "json_slog",
+			line:    `{"time":"2021-06-05T23:50:00Z","level":"INFO","msg":"foo bar","user":"walrus"}`,
+			message: "foo bar",
+			fields:  map[string]any{"user": "walrus"},
+		},
+		{
+			name:    "syslog-rfc3164",
+			line:    `<13>Oct 11 22:14:15 myhost myapp[123]: Sample`,
+			message: "Sample",
+			fields:  map[string]any{"appname": "myapp", "facility": "user", "hostname": "myhost", "pid": "123"},
+		},
+		{
+			name:    "syslog-rfc5424",
+			line:    `<165>1 2003-10-11T22:14:15.003Z myhost myapp 123 ID47 [exampleSDID@32473 iut="3" eventSource="App \"x\""][meta a="b"] Sample`,
+			message: "Sample",
+			fields: map[string]any{
+				"appname":           "myapp",
+				"exampleSDID@32473": map[string]any{"eventSource": `App "x"`, "iut": "3"},
+				"facility":          "local4",
+				"hostname":          "myhost",
+				"meta":              map[string]any{"a": "b"},
+				"msgid":             "ID47",
+				"pid":               "123",
+			},
+		},
+		{
+			name:    "syslog-rfc5424_nil_values",
+			line:    "<14>1 - - - - - - \uFEFFSample",
+			message: "Sample",
+			fields:  map[string]any{"facility": "user"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			log := ParseUsingAnyParser(tc.line)
+			if tc.message != log.Message {
+				t.Errorf("wrong message\nwanted: %q\ngot:    %q", tc.message, log.Message)
+			}
+			if tc.logger != log.Logger {
+				t.Errorf("wrong logger\nwanted: %q\ngot:    %q", tc.logger, log.Logger)
+			}
+			if tc.caller != log.Caller {
+				t.Errorf("wrong caller\nwanted: %q\ngot:    %q", tc.caller, log.Caller)
+			}
+			if fmt.Sprint(tc.fields) != fmt.Sprint(log.Fields) {
+				t.Errorf("wrong fields\nwanted: %v\ngot:    %v", tc.fields, log.Fields)
+			}
+		})
+	}
+}
+
 func nullTimeString(t null.Time) string {
 	if t.Valid {
 		return t.Time.Format(time.RFC3339)
@@ -101,3 +300,170 @@ func nullTimeString(t null.Time) string {
 func timeEquals(t1, t2 null.Time) bool {
 	return nullTimeString(t1) == nullTimeString(t2)
 }
+
+func TestJSONParser_keys(t *testing.T) {
+	p := JSONParser{LevelKey: "meta.sev", TimeKey: "meta.when"}
+	log, result := p.Parse(`{"level":"debug","meta":{"sev":"error","when":"2021-06-05T23:50:00Z"},"message":"foo bar"}`)
+	if result == ResultNoMatch {
+		t.Fatal("no match")
+	}
+	if log.Level != loglevel.Error {
+		t.Errorf("wrong log level\nwanted: %s\ngot:    %s", loglevel.Error, log.Level)
+	}
+	wantTime := null.TimeFrom(time.Date(2021, 6, 5, 23, 50, 0, 0, time.UTC))
+	if !timeEquals(wantTime, log.Timestamp) {
+		t.Errorf("wrong time\nwanted: %s\ngot:    %s", nullTimeString(wantTime), nullTimeString(log.Timestamp))
+	}
+	wantFields := map[string]any{"level": "debug"}
+	if fmt.Sprint(wantFields) != fmt.Sprint(log.Fields) {
+		t.Errorf("wrong fields\nwanted: %v\ngot:    %v", wantFields, log.Fields)
+	}
+}
+
+func TestParseEpoch(t *testing.T) {
+	testCases := []struct {
+		name  string
+		value string
+		want  time.Time
+	}{
+		{
+			name:  "seconds",
+			value: "1623887400",
+			want:  time.Date(2021, 6, 16, 23, 50, 0, 0, time.UTC),
+		},
+		{
+			name:  "seconds with fraction",
+			value: "1623887400.5",
+			want:  time.Date(2021, 6, 16, 23, 50, 0, 500000000, time.UTC),
+		},
+		{
+			name:  "milliseconds",
+			value: "1623887400123",
+			want:  time.Date(2021, 6, 16, 23, 50, 0, 123000000, time.UTC),
+		},
+		{
+			name:  "milliseconds with fraction",
+			value: "1623887400123.5",
+			want:  time.Date(2021, 6, 16, 23, 50, 0, 123500000, time.UTC),
+		},
+		{
+			name:  "microseconds",
+			value: "1623887400123456",
+			want:  time.Date(2021, 6, 16, 23, 50, 0, 123456000, time.UTC),
+		},
+		{
+			name:  "nanoseconds",
+			value: "1623887400123456789",
+			want:  time.Date(2021, 6, 16, 23, 50, 0, 123456789, time.UTC),
+		},
+		{
+			name:  "below plausible range",
+			value: "20210618",
+		},
+		{
+			name:  "negative",
+			value: "-1623887400",
+		},
+		{
+			name:  "not a number",
+			value: "1623887400.12a",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := parseEpoch(tc.value)
+			if ok != !tc.want.IsZero() || !got.Equal(tc.want) {
+				t.Errorf("wrong time\nwanted: %v\ngot:    %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParseTimestamp_digitsAreNotEpoch(t *testing.T) {
+	if got := ParseTimestamp("1623887400"); got.Valid {
+		t.Errorf("wrong time\nwanted: <null>\ngot:    %v", got.Time)
+	}
+}
+
+func TestRegExParser_epoch(t *testing.T) {
+	p := RegExParser{
+		ParserName: "test",
+		Expression: regexp.MustCompile(`^(?P<time>\S+) (?P<level>\w+) (?P<message>.*)`),
+	}
+	var testCases = []struct {
+		line string
+		want null.Time
+	}{
+		{
+			line: "1623887400 INFO A walrus appears",
+			want: null.TimeFrom(time.Date(2021, 6, 16, 23, 50, 0, 0, time.UTC)),
+		},
+		{
+			line: "1623887400.123 INFO A walrus appears",
+			want: null.TimeFrom(time.Date(2021, 6, 16, 23, 50, 0, 123000000, time.UTC)),
+		},
+		{
+			line: "20210618 INFO A walrus appears",
+			want: null.Time{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.line, func(t *testing.T) {
+			log, _ := p.Parse(tc.line)
+			if log.Timestamp.Valid != tc.want.Valid || !log.Timestamp.Time.Equal(tc.want.Time) {
+				t.Errorf("wrong time\nwanted: %v\ngot:    %v", tc.want, log.Timestamp)
+			}
+		})
+	}
+}
+
+func TestParseTimestamp_timeOfDay(t *testing.T) {
+	now := time.Now()
+	want := time.Date(now.Year(), now.Month(), now.Day(), 14, 5, 0, 0, time.UTC)
+	for _, got := range []null.Time{
+		ParseTimestamp("14:05"),
+		parseTime("14:05:00", "15:04:05"),
+	} {
+		if !got.Valid || !got.Time.Equal(want) {
+			t.Errorf("wrong time\nwanted: %v\ngot:    %v", want, got.Time)
+		}
+	}
+}
+
+func TestParseTimestamp_noYear(t *testing.T) {
+	want := time.Date(time.Now().Year(), time.January, 1, 14, 5, 0, 0, time.UTC)
+	line := "Jan  1 14:05:00 myhost app: A walrus appears"
+	if log, _ := parseRFC3164(line, line); !log.Timestamp.Valid || !log.Timestamp.Time.Equal(want) {
+		t.Errorf("wrong time\nwanted: %v\ngot:    %v", want, log.Timestamp.Time)
+	}
+}
+
+func TestJSONParser_epochPrecision(t *testing.T) {
+	log, _ := JSONParser{}.Parse(`{"level":"info","ts":1623887400123456789,"duration":1.50}`)
+	want := time.Date(2021, 6, 16, 23, 50, 0, 123456789, time.UTC)
+	if !log.Timestamp.Valid || !log.Timestamp.Time.Equal(want) {
+		t.Errorf("wrong time\nwanted: %v\ngot:    %v", want, log.Timestamp.Time)
+	}
+	if got := fmt.Sprint(log.Fields["duration"]); got != "1.50" {
+		t.Errorf("wrong duration field\nwanted: %q\ngot:    %q", "1.50", got)
+	}
+}
+
+func TestJSONParser_levelScheme(t *testing.T) {
+	testCases := []struct {
+		scheme loglevel.NumericScheme
+		want   loglevel.Level
+	}{
+		{scheme: loglevel.SchemeAuto, want: loglevel.Unknown},
+		{scheme: loglevel.SchemeSyslog, want: loglevel.Warning},
+		{scheme: loglevel.SchemeOTel, want: loglevel.Trace},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.scheme.String(), func(t *testing.T) {
+			log, _ := JSONParser{LevelScheme: tc.scheme}.Parse(`{"level":4,"message":"foo bar"}`)
+			if log.Level != tc.want {
+				t.Errorf("wrong log level\nwanted: %s\ngot:    %s", tc.want, log.Level)
+			}
+		})
+	}
+}
@@ -21,4 +21,7 @@ package logparser
 type Reader interface {
 	Scan() bool
 	ParsedLog() ParsedLog
+	// Err returns the first error that made Scan stop, or nil if it
+	// stopped at the end of the input.
+	Err() error
 }
@@ -0,0 +1,318 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package logparser
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/acarl005/stripansi"
+	"github.com/apex/log"
+	"github.com/jilleJr/flog/pkg/loglevel"
+)
+
+const (
+	// detectLines is the maximum number of lines used to detect the log
+	// format of a stream.
+	detectLines = 20
+	// detectEarlyMatches is the number of lines that, if all matched by the
+	// same parser, is enough to detect the log format without waiting for
+	// detectLines number of lines.
+	detectEarlyMatches = 5
+)
+
+// maxRecordLines limits how many lines a single record may hold, so that
+// a stream of unparsable lines does not end up buffered in memory.
+const maxRecordLines = 10000
+
+// recordBuilder groups lines into records. A record is a header line that
+// was matched by a parser, followed by any continuation lines that no
+// parser matched, such as .NET log bodies, Java stack traces, or Go panics.
+//
+// Lines written by a container runtime, in the CRI or Docker json-file log
+// formats, are unwrapped first, and partial lines are joined, so that the
+// records are built from the payload written by the application.
+type recordBuilder struct {
+	// parser is the detected or forced parser. If nil, then all parsers
+	// are tried on each line.
+	parser       Parser
+	detected     bool
+	detectBuffer []inputLine
+	candidates   []Parser
+	votes        map[string]int
+	// levelScheme is the scheme of numeric levels in JSON logs, detected
+	// from the first level that is used by a single scheme.
+	levelScheme loglevel.NumericScheme
+
+	pending     ParsedLog
+	hasPending  bool
+	mayContinue bool
+	last        ParsedLog
+	ready       []ParsedLog
+	lineNumber  int
+	// partials are partial container log lines waiting to be joined,
+	// per stream.
+	partials map[string]inputLine
+}
+
+// forceParser skips format detection and only uses the given parser.
+func (b *recordBuilder) forceParser(parser Parser) {
+	b.parser = parser
+	b.detected = true
+}
+
+// add buffers lines until the log format has been detected, and then
+// parses the lines and either appends them to the pending record or starts
+// new records. Any completed records are made available via next.
+func (b *recordBuilder) add(line string) {
+	in, ok := b.unwrap(line)
+	if !ok {
+		return
+	}
+	b.addLine(in)
+}
+
+func (b *recordBuilder) addLine(in inputLine) {
+	if b.detected {
+		b.parseLine(in)
+		return
+	}
+	b.detectBuffer = append(b.detectBuffer, in)
+	b.voteParser(in.text)
+	if b.shouldDetect() {
+		b.detect()
+	}
+}
+
+// unwrap unwraps lines written by a container runtime and joins partial
+// lines. Returns false if the line is partial and waiting for the rest of
+// the line.
+func (b *recordBuilder) unwrap(line string) (inputLine, bool) {
+	b.lineNumber++
+	in, ok := unwrapContainerLine(line)
+	if !ok {
+		return inputLine{text: line, lineNumber: b.lineNumber, rawLines: 1}, true
+	}
+	in.lineNumber = b.lineNumber
+	in.rawLines = 1
+	if prev, ok := b.partials[in.stream]; ok {
+		delete(b.partials, in.stream)
+		prev.text += in.text
+		prev.rawLines++
+		prev.partial = in.partial
+		in = prev
+	}
+	if in.partial && in.rawLines < maxRecordLines {
+		if b.partials == nil {
+			b.partials = map[string]inputLine{}
+		}
+		b.partials[in.stream] = in
+		return inputLine{}, false
+	}
+	return in, true
+}
+
+// flushPartials adds any partial lines as if they were complete, in the
+// order they were read.
+func (b *recordBuilder) flushPartials() {
+	if len(b.partials) == 0 {
+		return
+	}
+	partials := make([]inputLine, 0, len(b.partials))
+	for _, in := range b.partials {
+		partials = append(partials, in)
+	}
+	sort.Slice(partials, func(i, j int) bool {
+		return partials[i].lineNumber < partials[j].lineNumber
+	})
+	b.partials = nil
+	for _, in := range partials {
+		b.addLine(in)
+	}
+}
+
+// voteParser counts which parser was the first to match the line.
+func (b *recordBuilder) voteParser(line string) {
+	for _, parser := range defaultParsers {
+		if _, result := parser.Parse(line); result != ResultNoMatch {
+			if b.votes == nil {
+				b.votes = map[string]int{}
+			}
+			if b.votes[parser.Name()] == 0 {
+				b.candidates = append(b.candidates, parser)
+			}
+			b.votes[parser.Name()]++
+			return
+		}
+	}
+}
+
+func (b *recordBuilder) shouldDetect() bool {
+	if len(b.detectBuffer) >= detectLines {
+		return true
+	}
+	return len(b.candidates) == 1 && b.votes[b.candidates[0].Name()] >= detectEarlyMatches
+}
+
+// detect locks onto the parser that matched most of the buffered lines,
+// where ties are won by the parser tried first, and then parses the
+// buffered lines.
+func (b *recordBuilder) detect() {
+	b.detected = true
+	for _, parser := range b.candidates {
+		if b.parser == nil || b.votes[parser.Name()] > b.votes[b.parser.Name()] {
+			b.parser = parser
+		}
+	}
+	if b.parser == nil {
+		log.WithField("lines", len(b.detectBuffer)).
+			Debug("Detected no log format, will try all parsers on each line")
+	} else {
+		log.WithFields(log.Fields{
+			"lines": len(b.detectBuffer),
+			"votes": b.votes,
+		}).Debugf("Detected log format: %s", b.parser.Name())
+	}
+	lines := b.detectBuffer
+	b.detectBuffer = nil
+	b.candidates = nil
+	b.votes = nil
+	logs := make([]ParsedLog, len(lines))
+	results := make([]ResultType, len(lines))
+	for i, line := range lines {
+		logs[i], results[i] = b.parse(line)
+	}
+	// Detect the level scheme from all buffered lines, so that the first
+	// logs are not left without a level.
+	for _, log := range logs {
+		b.detectLevelScheme(log)
+	}
+	for i, line := range lines {
+		b.addParsed(line, logs[i], results[i])
+	}
+}
+
+func (b *recordBuilder) parseLine(in inputLine) {
+	log, result := b.parse(in)
+	b.detectLevelScheme(log)
+	b.addParsed(in, log, result)
+}
+
+func (b *recordBuilder) parse(in inputLine) (ParsedLog, ResultType) {
+	if b.parser != nil {
+		return parseUsingParser(b.parser, in.text)
+	}
+	return parseUsingAnyParser(in.text)
+}
+
+// detectLevelScheme locks onto the scheme of the log's numeric level, if
+// no scheme is detected yet and the level is only used by a single scheme,
+// such as 30 from bunyan and pino.
+func (b *recordBuilder) detectLevelScheme(log ParsedLog) {
+	if b.levelScheme != loglevel.SchemeAuto || !log.levelNumber.Valid {
+		return
+	}
+	if scheme, ok := loglevel.DetectNumericScheme(log.levelNumber.Float64); ok {
+		b.levelScheme = scheme
+	}
+}
+
+func (b *recordBuilder) addParsed(in inputLine, log ParsedLog, result ResultType) {
+	if log.levelNumber.Valid && b.levelScheme != loglevel.SchemeAuto {
+		log.Level = loglevel.ParseNumericLevel(log.levelNumber.Float64, b.levelScheme)
+	}
+	if result == ResultNoMatch && b.hasPending && b.mayContinue &&
+		len(b.pending.Lines) < maxRecordLines {
+		b.pending.Lines = append(b.pending.Lines, in.text)
+		return
+	}
+	b.completePending()
+	in.applyTo(&log)
+	log.Lines = []string{in.text}
+	log.LineNumber = in.lineNumber
+	b.pending = log
+	b.hasPending = true
+	b.mayContinue = result == ResultMatchMayContinue
+	if !b.mayContinue {
+		// No need to wait for the next line, such as for JSON logs
+		b.completePending()
+	}
+}
+
+// flush detects the log format using the lines read so far, if not already
+// detected, and completes the pending record, if any. Partial container
+// log lines are completed as well.
+func (b *recordBuilder) flush() {
+	b.flushPartials()
+	if !b.detected {
+		b.detect()
+	}
+	b.completePending()
+}
+
+func (b *recordBuilder) completePending() {
+	if !b.hasPending {
+		return
+	}
+	log := b.pending
+	log.String = strings.Join(log.Lines, "\n")
+	if log.Message == "" && len(log.Lines) > 1 {
+		log.Message = continuationMessage(log.Lines[1:])
+	}
+	// Logs with a numeric level that could not be mapped keep the Unknown
+	// level, instead of being mistaken for the previous log.
+	if (log.Level == loglevel.Undefined || log.Level == loglevel.Unknown) && !log.levelNumber.Valid {
+		log.Level = b.last.Level
+	}
+	if !log.Timestamp.Valid {
+		log.Timestamp = b.last.Timestamp
+	}
+	b.last = log
+	b.ready = append(b.ready, log)
+	b.pending = ParsedLog{}
+	b.hasPending = false
+}
+
+// hasBuffered returns true if any lines are waiting to be completed into
+// records, either by more lines or by a flush.
+func (b *recordBuilder) hasBuffered() bool {
+	return b.hasPending || len(b.detectBuffer) > 0 || len(b.partials) > 0
+}
+
+// next pops the oldest completed record.
+func (b *recordBuilder) next() (ParsedLog, bool) {
+	if len(b.ready) == 0 {
+		return ParsedLog{}, false
+	}
+	log := b.ready[0]
+	b.ready[0] = ParsedLog{}
+	b.ready = b.ready[1:]
+	return log, true
+}
+
+// continuationMessage is used for formats where the message is written on
+// the lines after the header line, such as for .NET logs.
+func continuationMessage(lines []string) string {
+	trimmed := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line = strings.TrimSpace(stripansi.Strip(line)); line != "" {
+			trimmed = append(trimmed, line)
+		}
+	}
+	return strings.Join(trimmed, "\n")
+}
@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package logparser
+
+import (
+	"bufio"
+	"io"
+	"time"
+)
+
+// DefaultIdleFlush is the idle duration after which incomplete logs are
+// completed, as used by default by the flog command-line tool.
+const DefaultIdleFlush = time.Second
+
+// StreamReader reads logs from a stream that may pause for a long time
+// between logs, such as the output of a running program. Incomplete
+// multiline logs are completed after the stream has been idle for a while,
+// instead of waiting for the next log.
+type StreamReader struct {
+	lines   chan string
+	records recordBuilder
+	lastLog ParsedLog
+	idle    time.Duration
+	eof     bool
+	err     error
+}
+
+// NewStreamReader starts reading lines from a stream in the background.
+// Incomplete multiline logs are completed after no new lines have been
+// written for the idle duration.
+func NewStreamReader(r io.Reader, idle time.Duration) *StreamReader {
+	s := &StreamReader{
+		lines: make(chan string),
+		idle:  idle,
+	}
+	go s.readLines(r)
+	return s
+}
+
+func (r *StreamReader) readLines(stream io.Reader) {
+	scanner := bufio.NewScanner(stream)
+	for scanner.Scan() {
+		r.lines <- scanner.Text()
+	}
+	r.err = scanner.Err()
+	close(r.lines)
+}
+
+// ForceParser disables log format detection and only uses the given parser.
+func (r *StreamReader) ForceParser(parser Parser) {
+	r.records.forceParser(parser)
+}
+
+func (r *StreamReader) ParsedLog() ParsedLog {
+	return r.lastLog
+}
+
+// Err returns the error that stopped the reading of the stream, if any. It
+// is only safe to call after Scan has returned false.
+func (r *StreamReader) Err() error {
+	return r.err
+}
+
+func (r *StreamReader) Scan() bool {
+	for {
+		if log, ok := r.records.next(); ok {
+			r.lastLog = log
+			return true
+		}
+		if r.eof {
+			return false
+		}
+		var idle <-chan time.Time
+		var timer *time.Timer
+		if r.records.hasBuffered() {
+			timer = time.NewTimer(r.idle)
+			idle = timer.C
+		}
+		select {
+		case line, ok := <-r.lines:
+			if ok {
+				r.records.add(line)
+			} else {
+				r.eof = true
+				r.records.flush()
+			}
+		case <-idle:
+			r.records.flush()
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
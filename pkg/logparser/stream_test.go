@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package logparser
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jilleJr/flog/pkg/loglevel"
+)
+
+func TestStreamReader_idleFlush(t *testing.T) {
+	pr, pw := io.Pipe()
+	release := make(chan struct{})
+	go func() {
+		for i := 0; i < 3; i++ {
+			fmt.Fprintln(pw, "fail: Program[0]")
+		}
+		// Pause, like "kubectl logs -f" waiting for more logs
+		<-release
+		pw.Close()
+	}()
+	defer close(release)
+
+	r := NewStreamReader(pr, 50*time.Millisecond)
+	done := make(chan int)
+	go func() {
+		var count int
+		for count < 3 && r.Scan() {
+			if lvl := r.ParsedLog().Level; lvl != loglevel.Error {
+				t.Errorf("record %d: wrong log level\nwanted: %s\ngot:    %s", count, loglevel.Error, lvl)
+			}
+			count++
+		}
+		done <- count
+	}()
+
+	select {
+	case count := <-done:
+		if count != 3 {
+			t.Errorf("wrong number of records\nwanted: %d\ngot:    %d", 3, count)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for records while the stream was paused")
+	}
+}
+
+func TestStreamReader_completesRecordRightAway(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go fmt.Fprintln(pw, `{"level":"error","message":"foo"}`)
+
+	r := NewStreamReader(pr, time.Hour)
+	r.ForceParser(JSONParser{})
+	done := make(chan bool)
+	go func() {
+		done <- r.Scan()
+	}()
+
+	select {
+	case ok := <-done:
+		if !ok {
+			t.Fatal("no record")
+		}
+		if lvl := r.ParsedLog().Level; lvl != loglevel.Error {
+			t.Errorf("wrong log level\nwanted: %s\ngot:    %s", loglevel.Error, lvl)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a record that cannot continue")
+	}
+}
@@ -0,0 +1,162 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package logparser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/acarl005/stripansi"
+	"github.com/jilleJr/flog/pkg/loglevel"
+	"gopkg.in/guregu/null.v3"
+)
+
+// SyslogParser parses syslog messages in the RFC 3164 (BSD) format, such as:
+//
+//	<13>Oct 11 22:14:15 myhost myapp[123]: Sample
+//
+// or in the RFC 5424 format, if RFC5424 is true, such as:
+//
+//	<165>1 2003-10-11T22:14:15.003Z myhost myapp 123 ID47 [id key="value"] Sample
+//
+// The level is read from the severity of the PRI value. The hostname, app
+// name, process ID, message ID, and structured data are added as fields.
+type SyslogParser struct {
+	RFC5424 bool
+}
+
+func (p SyslogParser) Name() string {
+	if p.RFC5424 {
+		return "syslog-rfc5424"
+	}
+	return "syslog-rfc3164"
+}
+
+var (
+	// The PRI is optional for RFC 3164, as it is often left out when
+	// written to files such as /var/log/syslog.
+	rfc3164Regex = regexp.MustCompile(`^(?:<(\d{1,3})>)?([A-Z][a-z]{2} [ \d]\d \d\d:\d\d:\d\d) (\S+) (?:([^\s:\[]+)(?:\[([^\]]+)\])?: )?(.*)$`)
+	rfc5424Regex = regexp.MustCompile(`^<(\d{1,3})>\d{1,2} (\S+) (\S+) (\S+) (\S+) (\S+) (-|(?:\[(?:[^\]"\\]|\\.|"(?:[^"\\]|\\.)*")*\])+)(?: (.*))?$`)
+
+	sdElementRegex = regexp.MustCompile(`\[([^\s\]=]+)((?:\s+[^\s=\]]+="(?:[^"\\]|\\.)*")*)\s*\]`)
+	sdParamRegex   = regexp.MustCompile(`([^\s=\]]+)="((?:[^"\\]|\\.)*)"`)
+)
+
+func (p SyslogParser) Parse(line string) (ParsedLog, ResultType) {
+	stripped := stripansi.Strip(line)
+	if p.RFC5424 {
+		return parseRFC5424(line, stripped)
+	}
+	return parseRFC3164(line, stripped)
+}
+
+func parseRFC3164(line, stripped string) (ParsedLog, ResultType) {
+	m := rfc3164Regex.FindStringSubmatch(stripped)
+	if m == nil {
+		return ParsedLog{}, ResultNoMatch
+	}
+	log := ParsedLog{
+		String:  line,
+		Message: m[6],
+	}
+	if t, err := time.Parse(time.Stamp, m[2]); err == nil {
+		log.Timestamp = null.TimeFrom(timeDefaults(t, time.Stamp))
+	}
+	fields := map[string]any{}
+	if m[1] != "" {
+		readSyslogPRI(&log, fields, m[1])
+	}
+	addSyslogField(fields, "hostname", m[3])
+	addSyslogField(fields, "appname", m[4])
+	addSyslogField(fields, "pid", m[5])
+	log.Fields = fields
+	return log, ResultMatchMayContinue
+}
+
+func parseRFC5424(line, stripped string) (ParsedLog, ResultType) {
+	m := rfc5424Regex.FindStringSubmatch(stripped)
+	if m == nil {
+		return ParsedLog{}, ResultNoMatch
+	}
+	log := ParsedLog{
+		String:  line,
+		Message: strings.TrimPrefix(m[8], "\uFEFF"),
+	}
+	if m[2] != "-" {
+		log.Timestamp = parseTime(m[2], time.RFC3339Nano)
+	}
+	fields := map[string]any{}
+	readSyslogPRI(&log, fields, m[1])
+	addSyslogField(fields, "hostname", m[3])
+	addSyslogField(fields, "appname", m[4])
+	addSyslogField(fields, "pid", m[5])
+	addSyslogField(fields, "msgid", m[6])
+	for id, params := range parseStructuredData(m[7]) {
+		fields[id] = params
+	}
+	log.Fields = fields
+	return log, ResultMatchMayContinue
+}
+
+var syslogFacilities = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+func readSyslogPRI(log *ParsedLog, fields map[string]any, value string) {
+	pri, err := strconv.Atoi(value)
+	if err != nil || pri > 191 {
+		return
+	}
+	log.Level = loglevel.ParseNumericLevel(float64(pri%8), loglevel.SchemeSyslog)
+	fields["facility"] = syslogFacilities[pri/8]
+}
+
+// addSyslogField adds the field, unless it is empty or the nil value "-".
+func addSyslogField(fields map[string]any, key, value string) {
+	if value != "" && value != "-" {
+		fields[key] = value
+	}
+}
+
+// parseStructuredData parses RFC 5424 structured data, such as
+// [exampleSDID@32473 iut="3" eventSource="Application"], into a map of
+// parameters per SD-ID.
+func parseStructuredData(sd string) map[string]map[string]any {
+	if sd == "-" {
+		return nil
+	}
+	elements := map[string]map[string]any{}
+	for _, element := range sdElementRegex.FindAllStringSubmatch(sd, -1) {
+		params := map[string]any{}
+		for _, param := range sdParamRegex.FindAllStringSubmatch(element[2], -1) {
+			params[param[1]] = unescapeSDParam(param[2])
+		}
+		elements[element[1]] = params
+	}
+	return elements
+}
+
+var sdParamUnescaper = strings.NewReplacer(`\"`, `"`, `\\`, `\`, `\]`, `]`)
+
+func unescapeSDParam(value string) string {
+	return sdParamUnescaper.Replace(value)
+}
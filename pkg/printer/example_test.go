@@ -19,8 +19,11 @@
 package printer_test
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/jilleJr/flog/internal/apex/handlers/console"
@@ -39,8 +42,8 @@ time="2021-01-31T19:04:01+01:00" level=error msg="A walrus appears" animal=walru
 time="2021-01-31T19:04:01+01:00" level=fatal msg="A walrus appears" animal=walrus`
 
 	r := logparser.NewIOReader(strings.NewReader(input))
-	p := printer.NewConsolePrinter("test", &r, loglevel.Filter{MinLevel: loglevel.Warning}, log.InfoLevel)
-	log.SetHandler(console.New(os.Stdout, "flog: "))
+	omitted := &log.Logger{Handler: console.New(os.Stdout, "flog: "), Level: log.InfoLevel}
+	p := printer.NewConsolePrinter(context.Background(), os.Stdout, omitted, "test", &r, loglevel.Filter{MinLevel: loglevel.Warning}, printer.Options{})
 
 	for p.Next() {
 	}
@@ -64,8 +67,8 @@ func ExamplePrinter_logrus_ansi_multiline() {
 [31mFATA[0m[0000] A walrus appears                              [31manimal[0m=walrus`
 
 	r := logparser.NewIOReader(strings.NewReader(input))
-	p := printer.NewConsolePrinter("test", &r, loglevel.Filter{MinLevel: loglevel.Warning}, log.InfoLevel)
-	log.SetHandler(console.New(os.Stdout, "flog: "))
+	omitted := &log.Logger{Handler: console.New(os.Stdout, "flog: "), Level: log.InfoLevel}
+	p := printer.NewConsolePrinter(context.Background(), os.Stdout, omitted, "test", &r, loglevel.Filter{MinLevel: loglevel.Warning}, printer.Options{})
 
 	for p.Next() {
 	}
@@ -78,3 +81,142 @@ func ExamplePrinter_logrus_ansi_multiline() {
 	// [31mERRO[0m[0000] A walrus appears                              [31manimal[0m=walrus
 	// [31mFATA[0m[0000] A walrus appears                              [31manimal[0m=walrus
 }
+
+func ExamplePrinter_dotnet_multiline() {
+	input := `info: Program[0]
+      Starting up
+fail: Program[0]
+      System.Exception: Something broke
+         at Program.Main(String[] args) in Program.cs:line 12
+         at Program.<Main>(String[] args)
+dbug: Program[0]
+      Shutting down`
+
+	r := logparser.NewIOReader(strings.NewReader(input))
+	omitted := &log.Logger{Handler: console.New(os.Stdout, "flog: "), Level: log.InfoLevel}
+	p := printer.NewConsolePrinter(context.Background(), os.Stdout, omitted, "test", &r, loglevel.Filter{MinLevel: loglevel.Warning}, printer.Options{})
+
+	for p.Next() {
+	}
+	p.PrintOmittedLogs()
+
+	// Output:
+	// [90m[3mflog: [0m[34m INFO:[0m [90m[3mOmitted logs from: test  [0m [34mInformation[0m=1[0m
+	// fail: Program[0]
+	//       System.Exception: Something broke
+	//          at Program.Main(String[] args) in Program.cs:line 12
+	//          at Program.<Main>(String[] args)
+	// [90m[3mflog: [0m[34m INFO:[0m [90m[3mOmitted logs from: test  [0m [34mDebug[0m=1[0m
+}
+
+func ExamplePrinter_context() {
+	input := `info: Program[0]
+dbug: Program[0]
+dbug: Program[0]
+fail: Program[0]
+info: Program[0]
+info: Program[0]
+info: Program[0]
+warn: Program[0]
+fail: Program[0]
+dbug: Program[0]`
+
+	r := logparser.NewIOReader(strings.NewReader(input))
+	omitted := &log.Logger{Handler: console.New(os.Stdout, "flog: "), Level: log.InfoLevel}
+	p := printer.NewConsolePrinter(context.Background(), os.Stdout, omitted, "test", &r, loglevel.Filter{MinLevel: loglevel.Error}, printer.Options{
+		BeforeContext: 1,
+		AfterContext:  1,
+	})
+
+	for p.Next() {
+	}
+	p.PrintOmittedLogs()
+
+	// Output:
+	// [90m[3mflog: [0m[34m INFO:[0m [90m[3mOmitted logs from: test  [0m [34mDebug[0m=1[0m [34mInformation[0m=1[0m
+	// dbug: Program[0]
+	// fail: Program[0]
+	// info: Program[0]
+	// [90m[3mflog: [0m[34m INFO:[0m [90m[3mOmitted logs from: test  [0m [34mInformation[0m=2[0m
+	// --
+	// warn: Program[0]
+	// fail: Program[0]
+	// dbug: Program[0]
+}
+
+func ExamplePrinter_contextTime() {
+	input := `{"level":"debug","timestamp":"2021-06-05T23:50:00Z","message":"too early"}
+{"level":"debug","timestamp":"2021-06-05T23:50:07Z","message":"before"}
+{"level":"error","timestamp":"2021-06-05T23:50:10Z","message":"match"}
+{"level":"debug","timestamp":"2021-06-05T23:50:12Z","message":"after"}
+{"level":"debug","timestamp":"2021-06-05T23:50:20Z","message":"too late"}`
+
+	r := logparser.NewIOReader(strings.NewReader(input))
+	omitted := &log.Logger{Handler: console.New(os.Stdout, "flog: "), Level: log.InfoLevel}
+	p := printer.NewConsolePrinter(context.Background(), os.Stdout, omitted, "test", &r, loglevel.Filter{MinLevel: loglevel.Error}, printer.Options{
+		ContextTime: 5 * time.Second,
+	})
+
+	for p.Next() {
+	}
+	p.PrintOmittedLogs()
+
+	// Output:
+	// [90m[3mflog: [0m[34m INFO:[0m [90m[3mOmitted logs from: test  [0m [34mDebug[0m=1[0m
+	// {"level":"debug","timestamp":"2021-06-05T23:50:07Z","message":"before"}
+	// {"level":"error","timestamp":"2021-06-05T23:50:10Z","message":"match"}
+	// {"level":"debug","timestamp":"2021-06-05T23:50:12Z","message":"after"}
+	// [90m[3mflog: [0m[34m INFO:[0m [90m[3mOmitted logs from: test  [0m [34mDebug[0m=1[0m
+}
+
+func ExampleNewJSONPrinter() {
+	input := `time="2021-01-31T19:04:01+01:00" level=debug msg="A walrus appears" animal=walrus
+time="2021-01-31T19:04:01+01:00" level=warning msg="A walrus appears" animal=walrus`
+
+	r := logparser.NewIOReader(strings.NewReader(input))
+	p := printer.NewJSONPrinter(context.Background(), os.Stdout, nil, "test", &r, loglevel.Filter{MinLevel: loglevel.Warning}, printer.Options{})
+
+	for p.Next() {
+	}
+
+	// Output:
+	// {"level":"Warning","timestamp":"2021-01-31T19:04:01+01:00","message":"A walrus appears","fields":{"animal":"walrus"},"source":"test","line":2,"text":"time=\"2021-01-31T19:04:01+01:00\" level=warning msg=\"A walrus appears\" animal=walrus"}
+}
+
+func ExampleNewLogfmtPrinter() {
+	input := `{"level":"debug","timestamp":"2021-06-05T23:50:00Z","message":"foo bar","user":"walrus"}
+{"level":"error","timestamp":"2021-06-05T23:50:00Z","message":"foo bar","user":"walrus"}`
+
+	r := logparser.NewIOReader(strings.NewReader(input))
+	p := printer.NewLogfmtPrinter(context.Background(), os.Stdout, nil, "test", &r, loglevel.Filter{MinLevel: loglevel.Warning}, printer.Options{})
+
+	for p.Next() {
+	}
+
+	// Output:
+	// level=Error timestamp=2021-06-05T23:50:00Z message="foo bar" user=walrus source=test line=2
+}
+
+func ExamplePrinter_maxCount() {
+	input := `info: Program[0]
+fail: Program[0]
+info: Program[0]
+fail: Program[0]
+info: Program[0]`
+
+	r := logparser.NewIOReader(strings.NewReader(input))
+	p := printer.NewConsolePrinter(context.Background(), os.Stdout, nil, "test", &r, loglevel.Filter{MinLevel: loglevel.Error}, printer.Options{
+		AfterContext: 1,
+		MaxCount:     1,
+	})
+
+	for p.Next() {
+	}
+	p.PrintOmittedLogs()
+	fmt.Println("Matched:", p.MatchedLevels())
+
+	// Output:
+	// fail: Program[0]
+	// info: Program[0]
+	// Matched: Error
+}
@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package printer
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/jilleJr/flog/pkg/loglevel"
+	"github.com/jilleJr/flog/pkg/logparser"
+)
+
+// NewJSONPrinter returns a printer that writes each log as a normalized
+// JSON object on a single line.
+func NewJSONPrinter(ctx context.Context, out io.Writer, omitted log.Interface, name string, p logparser.Reader, filter loglevel.Filter, opts Options) Printer {
+	return newPrinter(ctx, out, omitted, name, jsonFormatter{}, p, filter, opts)
+}
+
+type jsonLog struct {
+	Level      string         `json:"level"`
+	Timestamp  string         `json:"timestamp,omitempty"`
+	Message    string         `json:"message"`
+	Logger     string         `json:"logger,omitempty"`
+	Caller     string         `json:"caller,omitempty"`
+	Fields     map[string]any `json:"fields,omitempty"`
+	Source     string         `json:"source"`
+	LineNumber int            `json:"line"`
+	Text       string         `json:"text"`
+}
+
+type jsonFormatter struct{}
+
+func (jsonFormatter) FormatLog(name string, parsed logparser.ParsedLog) string {
+	obj := jsonLog{
+		Level:      parsed.Level.String(),
+		Message:    parsed.Message,
+		Logger:     parsed.Logger,
+		Caller:     parsed.Caller,
+		Fields:     parsed.Fields,
+		Source:     name,
+		LineNumber: parsed.LineNumber,
+		Text:       parsed.String,
+	}
+	if parsed.Timestamp.Valid {
+		obj.Timestamp = parsed.Timestamp.Time.Format(time.RFC3339Nano)
+	}
+	b, err := json.Marshal(obj)
+	if err != nil {
+		// Only happens if the fields contains unsupported values
+		obj.Fields = nil
+		b, _ = json.Marshal(obj)
+	}
+	return string(b)
+}
+
+func (jsonFormatter) ChunkSeparator() string {
+	return ""
+}
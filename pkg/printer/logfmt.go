@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package printer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/apex/log"
+	"github.com/jilleJr/flog/pkg/loglevel"
+	"github.com/jilleJr/flog/pkg/logparser"
+)
+
+// NewLogfmtPrinter returns a printer that writes each log as logfmt
+// key-value pairs on a single line.
+func NewLogfmtPrinter(ctx context.Context, out io.Writer, omitted log.Interface, name string, p logparser.Reader, filter loglevel.Filter, opts Options) Printer {
+	return newPrinter(ctx, out, omitted, name, logfmtFormatter{}, p, filter, opts)
+}
+
+type logfmtFormatter struct{}
+
+func (logfmtFormatter) FormatLog(name string, parsed logparser.ParsedLog) string {
+	var b strings.Builder
+	writeLogfmtPair(&b, "level", parsed.Level.String())
+	if parsed.Timestamp.Valid {
+		writeLogfmtPair(&b, "timestamp", parsed.Timestamp.Time.Format(time.RFC3339Nano))
+	}
+	writeLogfmtPair(&b, "message", parsed.Message)
+	if parsed.Logger != "" {
+		writeLogfmtPair(&b, "logger", parsed.Logger)
+	}
+	if parsed.Caller != "" {
+		writeLogfmtPair(&b, "caller", parsed.Caller)
+	}
+	keys := make([]string, 0, len(parsed.Fields))
+	for key := range parsed.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		writeLogfmtPair(&b, logfmtFieldKey(key), logfmtValueString(parsed.Fields[key]))
+	}
+	writeLogfmtPair(&b, "source", name)
+	writeLogfmtPair(&b, "line", strconv.Itoa(parsed.LineNumber))
+	return b.String()
+}
+
+func (logfmtFormatter) ChunkSeparator() string {
+	return ""
+}
+
+func writeLogfmtPair(b *strings.Builder, key, value string) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(key)
+	b.WriteByte('=')
+	if needsLogfmtQuoting(value) {
+		b.WriteString(strconv.Quote(value))
+	} else {
+		b.WriteString(value)
+	}
+}
+
+// logfmtBuiltinKeys are the keys written for all logs. Fields with the same
+// names are prefixed with "fields.", similar to how they are nested in the
+// JSON output.
+var logfmtBuiltinKeys = map[string]bool{
+	"level":     true,
+	"timestamp": true,
+	"message":   true,
+	"logger":    true,
+	"caller":    true,
+	"source":    true,
+	"line":      true,
+}
+
+// logfmtFieldKey returns the key to write a field with. Keys cannot be
+// quoted in logfmt, so characters that would need quoting are replaced
+// with underscores.
+func logfmtFieldKey(key string) string {
+	key = strings.Map(func(r rune) rune {
+		if r == '=' || r == '"' || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return '_'
+		}
+		return r
+	}, key)
+	if key == "" {
+		key = "_"
+	}
+	if logfmtBuiltinKeys[key] {
+		return "fields." + key
+	}
+	return key
+}
+
+func needsLogfmtQuoting(value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, r := range value {
+		if r == '=' || r == '"' || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func logfmtValueString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]any, []any:
+		if b, err := json.Marshal(v); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprint(value)
+}
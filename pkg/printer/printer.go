@@ -19,101 +19,361 @@
 package printer
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/acarl005/stripansi"
 	"github.com/apex/log"
 	"github.com/jilleJr/flog/pkg/loglevel"
 	"github.com/jilleJr/flog/pkg/logparser"
+	"github.com/jilleJr/flog/pkg/where"
+	"gopkg.in/guregu/null.v3"
 )
 
 type Printer interface {
 	Next() bool
 	PrintOmittedLogs()
+	// MatchedLevels returns the levels of all logs so far that matched the
+	// filters, combined into a single bitmask.
+	MatchedLevels() loglevel.Level
 }
 
+// Options holds optional settings for a printer.
+type Options struct {
+	// BeforeContext is the number of omitted logs to print before each
+	// matching log.
+	BeforeContext int
+	// AfterContext is the number of omitted logs to print after each
+	// matching log.
+	AfterContext int
+	// ContextTime prints omitted logs timestamped within this duration
+	// before or after each matching log.
+	ContextTime time.Duration
+	// Where omits logs whose fields do not match the expression.
+	// Ignored if nil.
+	Where where.Expr
+	// Grep omits logs that do not match any of the patterns.
+	// Ignored if empty.
+	Grep []*regexp.Regexp
+	// ExcludeGrep omits logs that match any of the patterns.
+	ExcludeGrep []*regexp.Regexp
+	// IdleFlush prints the "Omitted logs" message after no new logs have
+	// been read for this duration, instead of waiting for the next printed
+	// log. Ignored if zero.
+	IdleFlush time.Duration
+	// Prefix prepends each printed line with the name of the file it was
+	// read from, such as "[app.log] ". Only used by the console printer.
+	Prefix bool
+	// MaxCount stops reading logs after this many logs have matched the
+	// filters, and their after context has been printed. Ignored if zero.
+	MaxCount int
+}
+
+func (o Options) hasContext() bool {
+	return o.BeforeContext > 0 || o.AfterContext > 0 || o.ContextTime > 0
+}
+
+// maxContextTimeLogs limits how many logs are kept in memory while waiting
+// for a matching log when using Options.ContextTime.
+const maxContextTimeLogs = 10000
+
 type consolePrinter struct {
+	ctx           context.Context
+	out           io.Writer
+	omitted       log.Interface
 	name          string
+	formatter     logFormatter
 	parser        logparser.Reader
 	filter        loglevel.Filter
+	opts          Options
 	levelsSkipped map[loglevel.Level]int
+	grepSkipped   int
 	skippedAny    bool
-	loggingLevel  log.Level
+	printedAny    bool
+	skippedChunk  bool
+	beforeContext logRing
+	afterContext  int
+	afterUntil    null.Time
+	scanned       chan logparser.ParsedLog
+	matched       int
+	matchedLevels loglevel.Level
 }
 
-func NewConsolePrinter(name string, p logparser.Reader, filter loglevel.Filter, loggingLevel log.Level) Printer {
+// logFormatter formats logs written by a printer.
+type logFormatter interface {
+	FormatLog(name string, parsed logparser.ParsedLog) string
+	// ChunkSeparator is printed between non-adjacent logs when using
+	// context. Empty string means no separator.
+	ChunkSeparator() string
+}
+
+type rawFormatter struct {
+	prefix bool
+}
+
+func (f rawFormatter) FormatLog(name string, parsed logparser.ParsedLog) string {
+	if !f.prefix {
+		return parsed.String
+	}
+	prefix := "[" + name + "] "
+	return prefix + strings.ReplaceAll(parsed.String, "\n", "\n"+prefix)
+}
+
+func (rawFormatter) ChunkSeparator() string {
+	return "--"
+}
+
+// NewConsolePrinter returns a printer that writes the logs as-is to out.
+//
+// The "Omitted logs" summaries are logged at info level to the omitted
+// logger, or discarded if it is nil. The printer stops reading logs when the
+// context is cancelled, after which PrintOmittedLogs should be called to log
+// the last summary.
+func NewConsolePrinter(ctx context.Context, out io.Writer, omitted log.Interface, name string, p logparser.Reader, filter loglevel.Filter, opts Options) Printer {
+	return newPrinter(ctx, out, omitted, name, rawFormatter{prefix: opts.Prefix}, p, filter, opts)
+}
+
+func newPrinter(ctx context.Context, out io.Writer, omitted log.Interface, name string, formatter logFormatter, p logparser.Reader, filter loglevel.Filter, opts Options) *consolePrinter {
+	// Negative context sizes are treated as no context
+	if opts.BeforeContext < 0 {
+		opts.BeforeContext = 0
+	}
+	if opts.AfterContext < 0 {
+		opts.AfterContext = 0
+	}
+	ringSize := opts.BeforeContext
+	if opts.ContextTime > 0 && ringSize < maxContextTimeLogs {
+		ringSize = maxContextTimeLogs
+	}
 	return &consolePrinter{
+		ctx:           ctx,
+		out:           out,
+		omitted:       omitted,
 		name:          name,
+		formatter:     formatter,
 		parser:        p,
 		filter:        filter,
+		opts:          opts,
 		levelsSkipped: map[loglevel.Level]int{},
 		skippedAny:    false,
-		loggingLevel:  loggingLevel,
+		beforeContext: newLogRing(ringSize),
 	}
 }
 
 func (p *consolePrinter) Next() bool {
-	if !p.parser.Scan() {
+	if p.reachedMaxCount() && p.afterContext == 0 {
+		return false
+	}
+	parsed, ok := p.scan()
+	if !ok {
 		return false
 	}
-	parsed := p.parser.ParsedLog()
 	log.WithFields(log.Fields{
 		"message": parsed.String,
 		"level":   parsed.Level,
 	}).Debugf("Parsed log from: %s", p.name)
 
-	if shouldIncludeLogInOutput(parsed.Level, p.filter) {
+	if !p.reachedMaxCount() && p.shouldInclude(parsed) {
+		p.matched++
+		p.matchedLevels |= parsed.Level
+		p.trimBeforeContext(parsed)
 		if p.skippedAny {
-			p.PrintOmittedLogs()
-			p.levelsSkipped = map[loglevel.Level]int{}
-			p.skippedAny = false
+			p.flushOmittedLogs()
 		}
-		fmt.Println(parsed.String)
-	} else {
-		p.skippedAny = true
-		if i, ok := p.levelsSkipped[parsed.Level]; ok {
-			p.levelsSkipped[parsed.Level] = i + 1
-		} else {
-			p.levelsSkipped[parsed.Level] = 1
+		if p.skippedChunk {
+			if sep := p.formatter.ChunkSeparator(); sep != "" && p.opts.hasContext() && p.printedAny {
+				fmt.Fprintln(p.out, sep)
+			}
+			p.skippedChunk = false
+		}
+		for {
+			before, ok := p.beforeContext.Pop()
+			if !ok {
+				break
+			}
+			p.printLog(before)
 		}
+		p.printLog(parsed)
+		p.afterContext = p.opts.AfterContext
+		if p.opts.ContextTime > 0 && parsed.Timestamp.Valid {
+			p.afterUntil = null.TimeFrom(parsed.Timestamp.Time.Add(p.opts.ContextTime))
+		}
+	} else if p.afterContext > 0 {
+		p.afterContext--
+		p.printLog(parsed)
+	} else if p.isWithinAfterContextTime(parsed) {
+		p.printLog(parsed)
+	} else {
+		p.pushBeforeContext(parsed)
 	}
 	return true
 }
 
-func shouldIncludeLogInOutput(lvl loglevel.Level, filter loglevel.Filter) bool {
-	if filter.WhitelistMask != loglevel.Undefined && filter.WhitelistMask&lvl == loglevel.Undefined {
-		return false
-	}
+func (p *consolePrinter) MatchedLevels() loglevel.Level {
+	return p.matchedLevels
+}
 
-	if lvl != loglevel.Unknown && lvl != loglevel.Undefined {
-		if filter.MinLevel != loglevel.Undefined && lvl < filter.MinLevel {
-			return false
+func (p *consolePrinter) reachedMaxCount() bool {
+	return p.opts.MaxCount > 0 && p.matched >= p.opts.MaxCount
+}
+
+// scan reads the next log. The reader is only scanned in the background if
+// needed to stop on context cancellation or to flush on idle, as it may
+// block for a long time.
+func (p *consolePrinter) scan() (logparser.ParsedLog, bool) {
+	if p.opts.IdleFlush <= 0 && p.ctx.Done() == nil {
+		if !p.parser.Scan() {
+			return logparser.ParsedLog{}, false
 		}
+		return p.parser.ParsedLog(), true
+	}
+	if p.scanned == nil {
+		p.scanned = make(chan logparser.ParsedLog)
+		go func() {
+			defer close(p.scanned)
+			for p.parser.Scan() {
+				select {
+				case p.scanned <- p.parser.ParsedLog():
+				case <-p.ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	var idle <-chan time.Time
+	if p.opts.IdleFlush > 0 {
+		timer := time.NewTimer(p.opts.IdleFlush)
+		defer timer.Stop()
+		idle = timer.C
+	}
+	for {
+		select {
+		case parsed, ok := <-p.scanned:
+			return parsed, ok
+		case <-p.ctx.Done():
+			return logparser.ParsedLog{}, false
+		case <-idle:
+			p.flushOmittedLogs()
+		}
+	}
+}
 
-		if filter.MaxLevel != loglevel.Undefined && lvl > filter.MaxLevel {
-			return false
+func (p *consolePrinter) shouldInclude(parsed logparser.ParsedLog) bool {
+	return shouldIncludeLogInOutput(parsed, p.filter) &&
+		shouldIncludeFieldsInOutput(parsed, p.opts.Where) &&
+		p.shouldIncludeGrep(parsed)
+}
+
+func (p *consolePrinter) shouldIncludeGrep(parsed logparser.ParsedLog) bool {
+	if len(p.opts.Grep) == 0 && len(p.opts.ExcludeGrep) == 0 {
+		return true
+	}
+	return shouldIncludeTextInOutput(stripansi.Strip(parsed.String), p.opts.Grep, p.opts.ExcludeGrep)
+}
+
+func (p *consolePrinter) isWithinAfterContextTime(parsed logparser.ParsedLog) bool {
+	return p.afterUntil.Valid && parsed.Timestamp.Valid &&
+		!parsed.Timestamp.Time.After(p.afterUntil.Time)
+}
+
+func (p *consolePrinter) pushBeforeContext(parsed logparser.ParsedLog) {
+	p.trimBeforeContext(parsed)
+	if evicted, ok := p.beforeContext.Push(parsed); ok {
+		p.skipLog(evicted)
+	}
+}
+
+// trimBeforeContext omits the buffered logs that are neither within the
+// count nor the time window of the given log.
+func (p *consolePrinter) trimBeforeContext(parsed logparser.ParsedLog) {
+	for p.beforeContext.Len() > p.opts.BeforeContext {
+		oldest, _ := p.beforeContext.Peek()
+		if p.opts.ContextTime > 0 && oldest.Timestamp.Valid && parsed.Timestamp.Valid &&
+			parsed.Timestamp.Time.Sub(oldest.Timestamp.Time) <= p.opts.ContextTime {
+			break
 		}
-	} else if filter.BlacklistMask&loglevel.Unknown > 0 {
-		return false
+		p.beforeContext.Pop()
+		p.skipLog(oldest)
 	}
+}
 
-	if filter.BlacklistMask&lvl != loglevel.Undefined {
-		return false
+func (p *consolePrinter) printLog(parsed logparser.ParsedLog) {
+	name := p.name
+	if parsed.Source != "" {
+		name = parsed.Source
 	}
+	fmt.Fprintln(p.out, p.formatter.FormatLog(name, parsed))
+	p.printedAny = true
+}
 
-	return true
+func (p *consolePrinter) skipLog(parsed logparser.ParsedLog) {
+	p.skippedAny = true
+	p.skippedChunk = true
+	// Logs omitted by both their severity and the patterns are counted by
+	// their severity.
+	if shouldIncludeLogInOutput(parsed, p.filter) && !p.shouldIncludeGrep(parsed) {
+		p.grepSkipped++
+	} else {
+		p.levelsSkipped[parsed.Level]++
+	}
+}
+
+func shouldIncludeLogInOutput(parsed logparser.ParsedLog, filter loglevel.Filter) bool {
+	return filter.Includes(parsed.Level, parsed.Timestamp.Time, parsed.Timestamp.Valid)
+}
+
+func shouldIncludeFieldsInOutput(parsed logparser.ParsedLog, expr where.Expr) bool {
+	return expr == nil || expr.Match(parsed)
+}
+
+func shouldIncludeTextInOutput(text string, grep, excludeGrep []*regexp.Regexp) bool {
+	for _, re := range excludeGrep {
+		if re.MatchString(text) {
+			return false
+		}
+	}
+	if len(grep) == 0 {
+		return true
+	}
+	for _, re := range grep {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
 }
 
 func (p *consolePrinter) PrintOmittedLogs() {
+	for {
+		before, ok := p.beforeContext.Pop()
+		if !ok {
+			break
+		}
+		p.skipLog(before)
+	}
+	p.flushOmittedLogs()
+}
+
+func (p *consolePrinter) flushOmittedLogs() {
 	if !p.skippedAny {
 		return
 	}
 
-	if p.loggingLevel > log.InfoLevel {
-		return
+	if p.omitted != nil {
+		fields := getSkippedLevelsFields(p.levelsSkipped)
+		if p.grepSkipped > 0 {
+			fields[grepSkippedField] = p.grepSkipped
+		}
+		p.omitted.WithFields(fields).Infof("Omitted logs from: %s", p.name)
 	}
 
-	fields := getSkippedLevelsFields(p.levelsSkipped)
-	log.WithFields(fields).Infof("Omitted logs from: %s", p.name)
+	p.levelsSkipped = map[loglevel.Level]int{}
+	p.grepSkipped = 0
+	p.skippedAny = false
 }
 
 const (
@@ -121,18 +381,9 @@ const (
 	skippedAnsi = "\033[90m\033[3m" // gray and italic
 )
 
-const printableLevelsLen = 8
-
-var printableLevels = []loglevel.Level{
-	loglevel.Trace,
-	loglevel.Debug,
-	loglevel.Information,
-	loglevel.Warning,
-	loglevel.Error,
-	loglevel.Critical,
-	loglevel.Fatal,
-	loglevel.Panic,
-}
+// grepSkippedField is the field name used in the "Omitted logs" message for
+// logs omitted by the --grep and --exclude-grep patterns.
+const grepSkippedField = "Pattern"
 
 func getSkippedLevelsFields(skipped map[loglevel.Level]int) log.Fields {
 	fields := make(log.Fields, len(skipped))
@@ -19,10 +19,20 @@
 package printer
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
 	"github.com/jilleJr/flog/pkg/loglevel"
+	"github.com/jilleJr/flog/pkg/logparser"
+	"gopkg.in/guregu/null.v3"
 )
 
 func TestGetSkippedLevelsFields_Empty(t *testing.T) {
@@ -61,7 +71,7 @@ func TestShouldIncludeLogInOutput_MinLevel(t *testing.T) {
 
 	for i, tc := range testCases {
 		t.Run(fmt.Sprintf("%d/input/%v/minLevel/%v", i, tc.input, tc.minLevel), func(t *testing.T) {
-			got := shouldIncludeLogInOutput(tc.input, loglevel.Filter{MinLevel: tc.minLevel})
+			got := shouldIncludeLogInOutput(logparser.ParsedLog{Level: tc.input}, loglevel.Filter{MinLevel: tc.minLevel})
 			if got != tc.want {
 				t.Errorf("got %v, want %v", got, tc.want)
 			}
@@ -94,7 +104,7 @@ func TestShouldIncludeLogInOutput_MaxLevel(t *testing.T) {
 
 	for i, tc := range testCases {
 		t.Run(fmt.Sprintf("%d/input/%v/maxLevel/%v", i, tc.input, tc.maxLevel), func(t *testing.T) {
-			got := shouldIncludeLogInOutput(tc.input, loglevel.Filter{MaxLevel: tc.maxLevel})
+			got := shouldIncludeLogInOutput(logparser.ParsedLog{Level: tc.input}, loglevel.Filter{MaxLevel: tc.maxLevel})
 			if got != tc.want {
 				t.Errorf("got %v, want %v", got, tc.want)
 			}
@@ -132,7 +142,7 @@ func TestShouldIncludeLogInOutput_BlacklistMask(t *testing.T) {
 
 	for i, tc := range testCases {
 		t.Run(fmt.Sprintf("%d/input/%v/blacklistMask/%v", i, tc.input, tc.blacklistMask), func(t *testing.T) {
-			got := shouldIncludeLogInOutput(tc.input, loglevel.Filter{BlacklistMask: tc.blacklistMask})
+			got := shouldIncludeLogInOutput(logparser.ParsedLog{Level: tc.input}, loglevel.Filter{BlacklistMask: tc.blacklistMask})
 			if got != tc.want {
 				t.Errorf("got %v, want %v", got, tc.want)
 			}
@@ -180,10 +190,214 @@ func TestShouldIncludeLogInOutput_WhitelistMask(t *testing.T) {
 
 	for i, tc := range testCases {
 		t.Run(fmt.Sprintf("%d/input/%v/whitelistMask/%v", i, tc.input, tc.whitelistMask), func(t *testing.T) {
-			got := shouldIncludeLogInOutput(tc.input, loglevel.Filter{WhitelistMask: tc.whitelistMask})
+			got := shouldIncludeLogInOutput(logparser.ParsedLog{Level: tc.input}, loglevel.Filter{WhitelistMask: tc.whitelistMask})
 			if got != tc.want {
 				t.Errorf("got %v, want %v", got, tc.want)
 			}
 		})
 	}
 }
+
+func TestShouldIncludeLogInOutput_Time(t *testing.T) {
+	since := time.Date(2021, 6, 5, 12, 0, 0, 0, time.UTC)
+	before := time.Date(2021, 6, 5, 13, 0, 0, 0, time.UTC)
+	var testCases = []struct {
+		name  string
+		input null.Time
+		want  bool
+	}{
+		{
+			name:  "no timestamp",
+			input: null.Time{},
+			want:  true,
+		},
+		{
+			name:  "before since",
+			input: null.TimeFrom(since.Add(-time.Second)),
+			want:  false,
+		},
+		{
+			name:  "equal to since",
+			input: null.TimeFrom(since),
+			want:  true,
+		},
+		{
+			name:  "within window",
+			input: null.TimeFrom(since.Add(30 * time.Minute)),
+			want:  true,
+		},
+		{
+			name:  "equal to before",
+			input: null.TimeFrom(before),
+			want:  true,
+		},
+		{
+			name:  "after before",
+			input: null.TimeFrom(before.Add(time.Second)),
+			want:  false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := shouldIncludeLogInOutput(logparser.ParsedLog{Timestamp: tc.input}, loglevel.Filter{Since: since, Before: before})
+			if got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestShouldIncludeTextInOutput(t *testing.T) {
+	var testCases = []struct {
+		name        string
+		input       string
+		grep        []string
+		excludeGrep []string
+		want        bool
+	}{
+		{
+			name:  "no patterns",
+			input: "A walrus appears",
+			want:  true,
+		},
+		{
+			name:  "grep match",
+			input: "A walrus appears",
+			grep:  []string{"seal", "walrus"},
+			want:  true,
+		},
+		{
+			name:  "grep no match",
+			input: "A walrus appears",
+			grep:  []string{"seal"},
+			want:  false,
+		},
+		{
+			name:        "exclude match",
+			input:       "A walrus appears",
+			excludeGrep: []string{"walrus"},
+			want:        false,
+		},
+		{
+			name:        "exclude takes precedence",
+			input:       "A walrus appears",
+			grep:        []string{"walrus"},
+			excludeGrep: []string{"appears$"},
+			want:        false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := shouldIncludeTextInOutput(tc.input, compileRegexps(tc.grep), compileRegexps(tc.excludeGrep))
+			if got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func compileRegexps(patterns []string) []*regexp.Regexp {
+	var regexps []*regexp.Regexp
+	for _, pattern := range patterns {
+		regexps = append(regexps, regexp.MustCompile(pattern))
+	}
+	return regexps
+}
+
+func TestConsolePrinter_contextCancel(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go fmt.Fprintln(pw, `{"level":"error","message":"printed"}`+"\n"+`{"level":"debug","message":"omitted"}`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var out bytes.Buffer
+	omitted := memory.New()
+	r := logparser.NewStreamReader(pr, 10*time.Millisecond)
+	p := NewConsolePrinter(ctx, &out, &log.Logger{Handler: omitted, Level: log.InfoLevel}, "test", r, loglevel.Filter{MinLevel: loglevel.Error}, Options{})
+
+	if !p.Next() || !p.Next() {
+		t.Fatal("expected two logs before cancelling")
+	}
+	cancel()
+	if p.Next() {
+		t.Fatal("expected Next to return false after cancelling")
+	}
+	p.PrintOmittedLogs()
+
+	if want := `{"level":"error","message":"printed"}` + "\n"; out.String() != want {
+		t.Errorf("wrong output\nwanted: %q\ngot:    %q", want, out.String())
+	}
+	if len(omitted.Entries) != 1 {
+		t.Fatalf("wrong number of omitted logs messages\nwanted: %d\ngot:    %d", 1, len(omitted.Entries))
+	}
+	if got := omitted.Entries[0].Fields["Debug"]; got != 1 {
+		t.Errorf("wrong omitted Debug count\nwanted: %d\ngot:    %v", 1, got)
+	}
+}
+
+func TestConsolePrinter_negativeContext(t *testing.T) {
+	input := `{"level":"debug","message":"omitted"}
+{"level":"error","message":"printed"}`
+
+	var out bytes.Buffer
+	r := logparser.NewIOReader(strings.NewReader(input))
+	p := NewConsolePrinter(context.Background(), &out, nil, "test", &r, loglevel.Filter{MinLevel: loglevel.Error}, Options{
+		BeforeContext: -1,
+		AfterContext:  -2,
+	})
+	for p.Next() {
+	}
+
+	if want := `{"level":"error","message":"printed"}` + "\n"; out.String() != want {
+		t.Errorf("wrong output\nwanted: %q\ngot:    %q", want, out.String())
+	}
+}
+
+func TestConsolePrinter_omittedByLevelAndPattern(t *testing.T) {
+	input := `{"level":"debug","message":"A seal appears"}
+{"level":"info","message":"A seal appears"}
+{"level":"info","message":"A walrus appears"}`
+
+	var out bytes.Buffer
+	omitted := memory.New()
+	r := logparser.NewIOReader(strings.NewReader(input))
+	p := NewConsolePrinter(context.Background(), &out, &log.Logger{Handler: omitted, Level: log.InfoLevel}, "test", &r, loglevel.Filter{MinLevel: loglevel.Information}, Options{
+		Grep: compileRegexps([]string{"walrus"}),
+	})
+	for p.Next() {
+	}
+	p.PrintOmittedLogs()
+
+	if len(omitted.Entries) != 1 {
+		t.Fatalf("wrong number of omitted logs messages\nwanted: %d\ngot:    %d", 1, len(omitted.Entries))
+	}
+	fields := omitted.Entries[0].Fields
+	if got := fields["Debug"]; got != 1 {
+		t.Errorf("wrong omitted Debug count\nwanted: %d\ngot:    %v", 1, got)
+	}
+	if got := fields[grepSkippedField]; got != 1 {
+		t.Errorf("wrong omitted %s count\nwanted: %d\ngot:    %v", grepSkippedField, 1, got)
+	}
+}
+
+func TestLogfmtFormatter_fieldKeys(t *testing.T) {
+	parsed := logparser.ParsedLog{
+		Level:      loglevel.Information,
+		Message:    "A walrus appears",
+		LineNumber: 1,
+		Fields: map[string]any{
+			"level":      "verbose",
+			"source":     "app.go",
+			"user name":  "walrus",
+			`a="b"`:      "c",
+			"http.route": "/",
+		},
+	}
+	want := `level=Information message="A walrus appears" a__b_=c http.route=/ fields.level=verbose fields.source=app.go user_name=walrus source=test line=1`
+
+	if got := (logfmtFormatter{}).FormatLog("test", parsed); got != want {
+		t.Errorf("wrong output\nwanted: %s\ngot:    %s", want, got)
+	}
+}
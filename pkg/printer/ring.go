@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package printer
+
+import "github.com/jilleJr/flog/pkg/logparser"
+
+// logRing is a fixed-size ring buffer of logs. When full, pushing a new log
+// evicts the oldest one.
+type logRing struct {
+	buf   []logparser.ParsedLog
+	start int
+	len   int
+}
+
+func newLogRing(size int) logRing {
+	return logRing{buf: make([]logparser.ParsedLog, size)}
+}
+
+func (r *logRing) Len() int {
+	return r.len
+}
+
+// Push adds a log to the end of the ring. If the ring was full, then the
+// evicted log is returned.
+func (r *logRing) Push(log logparser.ParsedLog) (logparser.ParsedLog, bool) {
+	if len(r.buf) == 0 {
+		return log, true
+	}
+	if r.len < len(r.buf) {
+		r.buf[(r.start+r.len)%len(r.buf)] = log
+		r.len++
+		return logparser.ParsedLog{}, false
+	}
+	evicted := r.buf[r.start]
+	r.buf[r.start] = log
+	r.start = (r.start + 1) % len(r.buf)
+	return evicted, true
+}
+
+// Peek returns the oldest log in the ring without removing it.
+func (r *logRing) Peek() (logparser.ParsedLog, bool) {
+	if r.len == 0 {
+		return logparser.ParsedLog{}, false
+	}
+	return r.buf[r.start], true
+}
+
+// Pop removes and returns the oldest log in the ring.
+func (r *logRing) Pop() (logparser.ParsedLog, bool) {
+	if r.len == 0 {
+		return logparser.ParsedLog{}, false
+	}
+	log := r.buf[r.start]
+	r.buf[r.start] = logparser.ParsedLog{}
+	r.start = (r.start + 1) % len(r.buf)
+	r.len--
+	return log, true
+}
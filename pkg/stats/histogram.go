@@ -0,0 +1,231 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package stats
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jilleJr/flog/pkg/loglevel"
+	"github.com/jilleJr/flog/pkg/logparser"
+)
+
+// maxFilledBuckets limits how many empty buckets are added between the first
+// and last bucket, so a small interval over a long time span does not
+// produce an enormous chart.
+const maxFilledBuckets = 10000
+
+// Histogram counts logs per level in time buckets of a fixed interval. Only
+// the levels in loglevel.PrintableLevels are counted.
+type Histogram struct {
+	Interval time.Duration
+	// Untimestamped is the number of logs that were not counted because
+	// they have no timestamp.
+	Untimestamped int
+	buckets       map[time.Time]map[loglevel.Level]int
+}
+
+// Bucket is the count of logs per level that are timestamped within
+// Interval after Start.
+type Bucket struct {
+	Start  time.Time
+	Levels map[loglevel.Level]int
+}
+
+// NewHistogram returns an empty histogram with the given bucket interval.
+func NewHistogram(interval time.Duration) *Histogram {
+	return &Histogram{
+		Interval: interval,
+		buckets:  map[time.Time]map[loglevel.Level]int{},
+	}
+}
+
+// Add counts a log in the bucket of its timestamp.
+func (h *Histogram) Add(log logparser.ParsedLog) {
+	if !log.Timestamp.Valid {
+		h.Untimestamped++
+		return
+	}
+	if !isPrintableLevel(log.Level) {
+		return
+	}
+	start := log.Timestamp.Time.UTC().Truncate(h.Interval)
+	levels, ok := h.buckets[start]
+	if !ok {
+		levels = map[loglevel.Level]int{}
+		h.buckets[start] = levels
+	}
+	levels[log.Level]++
+}
+
+// AddAll counts all remaining logs from a reader.
+func (h *Histogram) AddAll(r logparser.Reader) {
+	for r.Scan() {
+		h.Add(r.ParsedLog())
+	}
+}
+
+// Buckets returns the buckets in chronological order, including empty
+// buckets between the first and last bucket.
+func (h *Histogram) Buckets() []Bucket {
+	starts := make([]time.Time, 0, len(h.buckets))
+	for start := range h.buckets {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+	if len(starts) == 0 {
+		return nil
+	}
+	first, last := starts[0], starts[len(starts)-1]
+	if int(last.Sub(first)/h.Interval) < maxFilledBuckets {
+		starts = starts[:0]
+		for t := first; !t.After(last); t = t.Add(h.Interval) {
+			starts = append(starts, t)
+		}
+	}
+	buckets := make([]Bucket, len(starts))
+	for i, start := range starts {
+		levels := h.buckets[start]
+		if levels == nil {
+			levels = map[loglevel.Level]int{}
+		}
+		buckets[i] = Bucket{Start: start, Levels: levels}
+	}
+	return buckets
+}
+
+// chartBar is one of the stacked parts of a bar in the histogram chart.
+type chartBar struct {
+	name   string
+	char   string
+	levels loglevel.Level
+}
+
+// chartBars are drawn in each bar of the chart. Critical, Fatal, and Panic
+// logs are drawn as errors so that they are not left out.
+var chartBars = []chartBar{
+	{"Error", "█", loglevel.Error | loglevel.Critical | loglevel.Fatal | loglevel.Panic},
+	{"Warning", "▒", loglevel.Warning},
+	{"Information", "░", loglevel.Information},
+}
+
+// WriteChart writes the histogram as a bar chart of Error, Warning, and
+// Information logs, where the longest bar is the given width.
+func (h *Histogram) WriteChart(w io.Writer, width int) error {
+	buckets := h.Buckets()
+	counts := make([][]int, len(buckets))
+	var max int
+	for i, b := range buckets {
+		counts[i] = make([]int, len(chartBars))
+		var total int
+		for j, bar := range chartBars {
+			for lvl, count := range b.Levels {
+				if lvl&bar.levels != loglevel.Undefined {
+					counts[i][j] += count
+				}
+			}
+			total += counts[i][j]
+		}
+		if total > max {
+			max = total
+		}
+	}
+
+	var legend []string
+	for _, bar := range chartBars {
+		legend = append(legend, bar.char+" "+bar.name)
+	}
+	if _, err := fmt.Fprintf(w, "Interval: %s  %s\n", h.Interval, strings.Join(legend, "  ")); err != nil {
+		return err
+	}
+	for i, b := range buckets {
+		var bar strings.Builder
+		var labels []string
+		for j, cb := range chartBars {
+			n := scaleBar(counts[i][j], max, width)
+			bar.WriteString(strings.Repeat(cb.char, n))
+			labels = append(labels, fmt.Sprintf("%c:%d", cb.name[0], counts[i][j]))
+		}
+		padding := width - len([]rune(bar.String()))
+		if padding < 0 {
+			padding = 0
+		}
+		if _, err := fmt.Fprintf(w, "%s  %s%s  %s\n",
+			b.Start.Format(time.RFC3339), bar.String(), strings.Repeat(" ", padding),
+			strings.Join(labels, " ")); err != nil {
+			return err
+		}
+	}
+	if h.Untimestamped > 0 {
+		if _, err := fmt.Fprintf(w, "Logs without timestamp: %d\n", h.Untimestamped); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scaleBar returns the length of a bar, where any non-zero count gets at
+// least one character.
+func scaleBar(count, max, width int) int {
+	if count == 0 || max == 0 {
+		return 0
+	}
+	n := count * width / max
+	if n == 0 {
+		return 1
+	}
+	return n
+}
+
+// WriteCSV writes the histogram as comma-separated values, with one row per
+// bucket and one column per level in loglevel.PrintableLevels.
+func (h *Histogram) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	header := []string{"start"}
+	for _, lvl := range loglevel.PrintableLevels {
+		header = append(header, lvl.String())
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, b := range h.Buckets() {
+		row := []string{b.Start.Format(time.RFC3339)}
+		for _, lvl := range loglevel.PrintableLevels {
+			row = append(row, strconv.Itoa(b.Levels[lvl]))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func isPrintableLevel(lvl loglevel.Level) bool {
+	for _, l := range loglevel.PrintableLevels {
+		if l == lvl {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package stats
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jilleJr/flog/pkg/loglevel"
+	"github.com/jilleJr/flog/pkg/logparser"
+)
+
+func TestHistogram_Buckets(t *testing.T) {
+	r := logparser.NewIOReader(strings.NewReader(testLogs))
+	h := NewHistogram(time.Minute)
+	h.AddAll(&r)
+
+	buckets := h.Buckets()
+	if len(buckets) != 3 {
+		t.Fatalf("wrong number of buckets\nwanted: %d\ngot:    %d", 3, len(buckets))
+	}
+	want := []struct {
+		start string
+		level loglevel.Level
+		count int
+	}{
+		{"2022-01-01T10:00:00Z", loglevel.Information, 1},
+		{"2022-01-01T10:01:00Z", loglevel.Information, 1},
+		{"2022-01-01T10:02:00Z", loglevel.Error, 1},
+	}
+	for i, w := range want {
+		if got := buckets[i].Start.Format(time.RFC3339); got != w.start {
+			t.Errorf("bucket %d: wrong start\nwanted: %s\ngot:    %s", i, w.start, got)
+		}
+		if got := buckets[i].Levels[w.level]; got != w.count {
+			t.Errorf("bucket %d: wrong %s count\nwanted: %d\ngot:    %d", i, w.level, w.count, got)
+		}
+	}
+	if h.Untimestamped != 1 {
+		t.Errorf("wrong number of logs without timestamp\nwanted: %d\ngot:    %d", 1, h.Untimestamped)
+	}
+}
+
+func TestHistogram_WriteCSV(t *testing.T) {
+	r := logparser.NewIOReader(strings.NewReader(testLogs))
+	h := NewHistogram(time.Minute)
+	h.AddAll(&r)
+
+	var buf bytes.Buffer
+	if err := h.WriteCSV(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `start,Trace,Debug,Information,Warning,Error,Critical,Fatal,Panic
+2022-01-01T10:00:00Z,0,0,1,0,0,0,0,0
+2022-01-01T10:01:00Z,0,0,1,1,0,0,0,0
+2022-01-01T10:02:00Z,0,0,0,0,1,0,0,0
+`
+	if buf.String() != want {
+		t.Errorf("wrong CSV\nwanted:\n%s\ngot:\n%s", want, buf.String())
+	}
+}
@@ -0,0 +1,183 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package stats summarizes logs by their levels, timestamps, and formats.
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jilleJr/flog/pkg/loglevel"
+	"github.com/jilleJr/flog/pkg/logparser"
+	"gopkg.in/guregu/null.v3"
+)
+
+// Stats holds counts of logs added to it.
+type Stats struct {
+	// Total is the number of logs.
+	Total int
+	// Levels is the number of logs per level.
+	Levels map[loglevel.Level]int
+	// First and Last are the earliest and latest timestamps of the logs.
+	First null.Time
+	Last  null.Time
+	// UnparseableLines is the number of lines that no parser matched.
+	UnparseableLines int
+	// Parsers is the number of logs matched per parser name.
+	Parsers map[string]int
+}
+
+// New returns an empty set of statistics.
+func New() *Stats {
+	return &Stats{
+		Levels:  map[loglevel.Level]int{},
+		Parsers: map[string]int{},
+	}
+}
+
+// Add counts a log.
+func (s *Stats) Add(log logparser.ParsedLog) {
+	s.Total++
+	s.Levels[log.Level]++
+	if log.Parser == "" {
+		s.UnparseableLines += len(log.Lines)
+	} else {
+		s.Parsers[log.Parser]++
+	}
+	if log.Timestamp.Valid {
+		t := log.Timestamp.Time
+		if !s.First.Valid || t.Before(s.First.Time) {
+			s.First = null.TimeFrom(t)
+		}
+		if !s.Last.Valid || t.After(s.Last.Time) {
+			s.Last = null.TimeFrom(t)
+		}
+	}
+}
+
+// AddAll counts all remaining logs from a reader.
+func (s *Stats) AddAll(r logparser.Reader) {
+	for r.Scan() {
+		s.Add(r.ParsedLog())
+	}
+}
+
+// Span returns the duration between the first and last timestamp.
+func (s *Stats) Span() time.Duration {
+	if !s.First.Valid || !s.Last.Valid {
+		return 0
+	}
+	return s.Last.Time.Sub(s.First.Time)
+}
+
+// PerMinute returns the average number of logs per minute between the first
+// and last timestamp, or zero if the logs do not span any time.
+func (s *Stats) PerMinute() float64 {
+	span := s.Span()
+	if span <= 0 {
+		return 0
+	}
+	return float64(s.Total) / span.Minutes()
+}
+
+// WriteTable writes the statistics as a human-readable table.
+func (s *Stats) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Total logs:\t%d\n", s.Total)
+	fmt.Fprintf(tw, "First timestamp:\t%s\n", formatTime(s.First))
+	fmt.Fprintf(tw, "Last timestamp:\t%s\n", formatTime(s.Last))
+	fmt.Fprintf(tw, "Time span:\t%s\n", s.Span())
+	fmt.Fprintf(tw, "Logs per minute:\t%.2f\n", s.PerMinute())
+	fmt.Fprintf(tw, "Unparseable lines:\t%d\n", s.UnparseableLines)
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "LEVEL\tLOGS")
+	for _, lvl := range s.sortedLevels() {
+		fmt.Fprintf(tw, "%s\t%d\n", lvl, s.Levels[lvl])
+	}
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "PARSER\tLOGS")
+	for _, name := range s.sortedParsers() {
+		fmt.Fprintf(tw, "%s\t%d\n", name, s.Parsers[name])
+	}
+	return tw.Flush()
+}
+
+type jsonStats struct {
+	Total            int            `json:"total"`
+	Levels           map[string]int `json:"levels"`
+	First            *time.Time     `json:"first,omitempty"`
+	Last             *time.Time     `json:"last,omitempty"`
+	SpanSeconds      float64        `json:"spanSeconds"`
+	PerMinute        float64        `json:"perMinute"`
+	UnparseableLines int            `json:"unparseableLines"`
+	Parsers          map[string]int `json:"parsers"`
+}
+
+// WriteJSON writes the statistics as an indented JSON object.
+func (s *Stats) WriteJSON(w io.Writer) error {
+	levels := make(map[string]int, len(s.Levels))
+	for lvl, count := range s.Levels {
+		levels[lvl.String()] = count
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(jsonStats{
+		Total:            s.Total,
+		Levels:           levels,
+		First:            s.First.Ptr(),
+		Last:             s.Last.Ptr(),
+		SpanSeconds:      s.Span().Seconds(),
+		PerMinute:        s.PerMinute(),
+		UnparseableLines: s.UnparseableLines,
+		Parsers:          s.Parsers,
+	})
+}
+
+func (s *Stats) sortedLevels() []loglevel.Level {
+	levels := make([]loglevel.Level, 0, len(s.Levels))
+	for lvl := range s.Levels {
+		levels = append(levels, lvl)
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i] < levels[j] })
+	return levels
+}
+
+func (s *Stats) sortedParsers() []string {
+	names := make([]string, 0, len(s.Parsers))
+	for name := range s.Parsers {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if s.Parsers[names[i]] != s.Parsers[names[j]] {
+			return s.Parsers[names[i]] > s.Parsers[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+func formatTime(t null.Time) string {
+	if !t.Valid {
+		return "-"
+	}
+	return t.Time.Format(time.RFC3339)
+}
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package stats
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jilleJr/flog/pkg/loglevel"
+	"github.com/jilleJr/flog/pkg/logparser"
+)
+
+const testLogs = `plain text before any log
+{"level":"info","timestamp":"2022-01-01T10:00:00Z","message":"a"}
+{"level":"error","timestamp":"2022-01-01T10:02:00Z","message":"b"}
+{"level":"info","timestamp":"2022-01-01T10:01:00Z","message":"c"}
+{"level":"warn","message":"d"}`
+
+func TestStats(t *testing.T) {
+	r := logparser.NewIOReader(strings.NewReader(testLogs))
+	s := New()
+	s.AddAll(&r)
+
+	if s.Total != 5 {
+		t.Errorf("wrong total\nwanted: %d\ngot:    %d", 5, s.Total)
+	}
+	if got := s.Levels[loglevel.Information]; got != 2 {
+		t.Errorf("wrong Information count\nwanted: %d\ngot:    %d", 2, got)
+	}
+	if got := s.Parsers["json"]; got != 4 {
+		t.Errorf("wrong json parser count\nwanted: %d\ngot:    %d", 4, got)
+	}
+	if s.UnparseableLines != 1 {
+		t.Errorf("wrong unparseable lines\nwanted: %d\ngot:    %d", 1, s.UnparseableLines)
+	}
+	if got := formatTime(s.First); got != "2022-01-01T10:00:00Z" {
+		t.Errorf("wrong first timestamp\nwanted: %s\ngot:    %s", "2022-01-01T10:00:00Z", got)
+	}
+	if got := formatTime(s.Last); got != "2022-01-01T10:02:00Z" {
+		t.Errorf("wrong last timestamp\nwanted: %s\ngot:    %s", "2022-01-01T10:02:00Z", got)
+	}
+	if got := s.PerMinute(); got != 2.5 {
+		t.Errorf("wrong logs per minute\nwanted: %v\ngot:    %v", 2.5, got)
+	}
+}
+
+func TestStats_WriteJSON(t *testing.T) {
+	r := logparser.NewIOReader(strings.NewReader(testLogs))
+	s := New()
+	s.AddAll(&r)
+
+	var buf bytes.Buffer
+	if err := s.WriteJSON(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{`"total": 5`, `"Information": 2`, `"json": 4`, `"first": "2022-01-01T10:00:00Z"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("missing %s in JSON output:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tui
+
+import (
+	"strings"
+
+	"github.com/jilleJr/flog/pkg/loglevel"
+	"github.com/jilleJr/flog/pkg/logparser"
+)
+
+// model holds the state of the viewer, separate from the drawing, so that
+// it can be tested without a terminal.
+type model struct {
+	filter loglevel.Filter
+	// hidden contains the levels toggled off by the user.
+	hidden   loglevel.Level
+	entries  []logparser.ParsedLog
+	visible  []int
+	omitted  map[loglevel.Level]int
+	expanded map[int]bool
+	// cursor and top are indexes into visible.
+	cursor int
+	top    int
+}
+
+func newModel(filter loglevel.Filter) *model {
+	return &model{
+		filter:   filter,
+		omitted:  map[loglevel.Level]int{},
+		expanded: map[int]bool{},
+	}
+}
+
+func (m *model) add(log logparser.ParsedLog) {
+	m.entries = append(m.entries, log)
+	i := len(m.entries) - 1
+	if m.includes(log) {
+		m.visible = append(m.visible, i)
+	} else {
+		m.omitted[log.Level]++
+	}
+}
+
+// includes uses the same filter as the printer, and additionally omits the
+// levels toggled off in the viewer.
+func (m *model) includes(log logparser.ParsedLog) bool {
+	return m.filter.Includes(log.Level, log.Timestamp.Time, log.Timestamp.Valid) &&
+		toggleLevelOf(log.Level)&m.hidden == loglevel.Undefined
+}
+
+// toggleLevelOf returns the level used for toggling, where logs without a
+// level are toggled together with the Unknown level.
+func toggleLevelOf(lvl loglevel.Level) loglevel.Level {
+	if lvl == loglevel.Undefined {
+		return loglevel.Unknown
+	}
+	return lvl
+}
+
+func (m *model) isHidden(lvl loglevel.Level) bool {
+	return m.hidden&lvl != loglevel.Undefined
+}
+
+func (m *model) toggleLevel(lvl loglevel.Level) {
+	m.hidden ^= lvl
+	m.refilter()
+}
+
+// refilter recalculates the visible entries, while keeping the cursor on the
+// same entry, or on the next visible entry if it became hidden.
+func (m *model) refilter() {
+	current, hasCurrent := m.current()
+	m.visible = m.visible[:0]
+	m.omitted = map[loglevel.Level]int{}
+	m.cursor = -1
+	for i, log := range m.entries {
+		if !m.includes(log) {
+			m.omitted[log.Level]++
+			continue
+		}
+		if m.cursor < 0 && (!hasCurrent || i >= current) {
+			m.cursor = len(m.visible)
+		}
+		m.visible = append(m.visible, i)
+	}
+	if m.cursor < 0 {
+		m.cursor = len(m.visible) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	if m.top > m.cursor {
+		m.top = m.cursor
+	}
+}
+
+// current returns the entry index under the cursor.
+func (m *model) current() (int, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.visible) {
+		return 0, false
+	}
+	return m.visible[m.cursor], true
+}
+
+func (m *model) moveCursor(delta int) {
+	m.cursor += delta
+	if m.cursor >= len(m.visible) {
+		m.cursor = len(m.visible) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+func (m *model) toggleExpanded() {
+	if i, ok := m.current(); ok && len(m.entries[i].Lines) > 1 {
+		m.expanded[i] = !m.expanded[i]
+	}
+}
+
+// rows returns the number of screen rows used by an entry.
+func (m *model) rows(entry int) int {
+	if m.expanded[entry] && len(m.entries[entry].Lines) > 1 {
+		return len(m.entries[entry].Lines)
+	}
+	return 1
+}
+
+// scrollToCursor moves the top of the screen so that the cursor is visible
+// within the given number of rows.
+func (m *model) scrollToCursor(height int) {
+	if m.cursor < m.top {
+		m.top = m.cursor
+	}
+	for m.top < m.cursor {
+		used := 0
+		for i := m.top; i <= m.cursor; i++ {
+			used += m.rows(m.visible[i])
+		}
+		if used <= height {
+			break
+		}
+		m.top++
+	}
+}
+
+// findNext moves the cursor to the next visible entry in the given
+// direction (1 or -1) that matches the predicate, and reports if any was
+// found. The search starts at the cursor if inclusive is true, or else
+// next to it.
+func (m *model) findNext(dir int, inclusive bool, match func(logparser.ParsedLog) bool) bool {
+	start := m.cursor
+	if !inclusive {
+		start += dir
+	}
+	for i := start; i >= 0 && i < len(m.visible); i += dir {
+		if match(m.entries[m.visible[i]]) {
+			m.cursor = i
+			return true
+		}
+	}
+	return false
+}
+
+func isErrorOrAbove(log logparser.ParsedLog) bool {
+	return log.Level >= loglevel.Error
+}
+
+// containsText returns a case-insensitive substring match function.
+func containsText(query string) func(logparser.ParsedLog) bool {
+	query = strings.ToLower(query)
+	return func(log logparser.ParsedLog) bool {
+		return strings.Contains(strings.ToLower(log.String), query)
+	}
+}
@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jilleJr/flog/pkg/loglevel"
+	"github.com/jilleJr/flog/pkg/logparser"
+)
+
+const testLogs = `info: Program[0]
+      Starting up
+dbug: Program[0]
+      Loading config
+fail: Program[0]
+      System.Exception: Something broke
+         at Program.Main(String[] args) in Program.cs:line 12
+info: Program[0]
+      Almost done
+fail: Program[0]
+      Shutting down`
+
+func newTestModel(t *testing.T, filter loglevel.Filter) *model {
+	m := newModel(filter)
+	r := logparser.NewIOReader(strings.NewReader(testLogs))
+	for r.Scan() {
+		m.add(r.ParsedLog())
+	}
+	if len(m.entries) != 5 {
+		t.Fatalf("wrong number of entries\nwanted: %d\ngot:    %d", 5, len(m.entries))
+	}
+	return m
+}
+
+func TestModel_filter(t *testing.T) {
+	m := newTestModel(t, loglevel.Filter{MinLevel: loglevel.Information})
+	if len(m.visible) != 4 {
+		t.Errorf("wrong number of visible entries\nwanted: %d\ngot:    %d", 4, len(m.visible))
+	}
+	if got := m.omitted[loglevel.Debug]; got != 1 {
+		t.Errorf("wrong omitted Debug count\nwanted: %d\ngot:    %d", 1, got)
+	}
+}
+
+func TestModel_toggleLevel(t *testing.T) {
+	m := newTestModel(t, loglevel.Filter{})
+	m.moveCursor(3) // second "info"
+
+	m.toggleLevel(loglevel.Information)
+	if len(m.visible) != 3 {
+		t.Fatalf("wrong number of visible entries\nwanted: %d\ngot:    %d", 3, len(m.visible))
+	}
+	if got := m.omitted[loglevel.Information]; got != 2 {
+		t.Errorf("wrong omitted Information count\nwanted: %d\ngot:    %d", 2, got)
+	}
+	if i, _ := m.current(); i != 4 {
+		t.Errorf("cursor did not move to next visible entry\nwanted: %d\ngot:    %d", 4, i)
+	}
+
+	m.toggleLevel(loglevel.Information)
+	if len(m.visible) != 5 {
+		t.Errorf("wrong number of visible entries\nwanted: %d\ngot:    %d", 5, len(m.visible))
+	}
+	if i, _ := m.current(); i != 4 {
+		t.Errorf("cursor did not stay on same entry\nwanted: %d\ngot:    %d", 4, i)
+	}
+}
+
+func TestModel_findNext(t *testing.T) {
+	m := newTestModel(t, loglevel.Filter{})
+
+	if !m.findNext(1, false, isErrorOrAbove) || m.cursor != 2 {
+		t.Errorf("wrong cursor after next error\nwanted: %d\ngot:    %d", 2, m.cursor)
+	}
+	if !m.findNext(1, false, isErrorOrAbove) || m.cursor != 4 {
+		t.Errorf("wrong cursor after next error\nwanted: %d\ngot:    %d", 4, m.cursor)
+	}
+	if m.findNext(1, false, isErrorOrAbove) {
+		t.Error("found error after last error")
+	}
+	if !m.findNext(-1, false, containsText("STARTING")) || m.cursor != 0 {
+		t.Errorf("wrong cursor after search\nwanted: %d\ngot:    %d", 0, m.cursor)
+	}
+}
+
+func TestModel_scrollToCursor(t *testing.T) {
+	m := newTestModel(t, loglevel.Filter{})
+	for i := range m.entries {
+		m.expanded[i] = true
+	}
+	m.moveCursor(2)
+	m.scrollToCursor(5)
+	if m.top != 1 {
+		t.Errorf("wrong top\nwanted: %d\ngot:    %d", 1, m.top)
+	}
+}
@@ -0,0 +1,394 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package tui contains an interactive terminal viewer for logs.
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/acarl005/stripansi"
+	"github.com/gdamore/tcell/v2"
+	"github.com/jilleJr/flog/pkg/loglevel"
+	"github.com/jilleJr/flog/pkg/logparser"
+	"github.com/mattn/go-runewidth"
+)
+
+// toggleKeys are the keys that toggle the levels on and off.
+var toggleKeys = []struct {
+	key   rune
+	level loglevel.Level
+}{
+	{'0', loglevel.Unknown},
+	{'1', loglevel.PrintableLevels[0]},
+	{'2', loglevel.PrintableLevels[1]},
+	{'3', loglevel.PrintableLevels[2]},
+	{'4', loglevel.PrintableLevels[3]},
+	{'5', loglevel.PrintableLevels[4]},
+	{'6', loglevel.PrintableLevels[5]},
+	{'7', loglevel.PrintableLevels[6]},
+	{'8', loglevel.PrintableLevels[7]},
+}
+
+// readBatchSize and readBatchDelay limit how often the screen is redrawn
+// while logs are still being read.
+const (
+	readBatchSize  = 1000
+	readBatchDelay = 100 * time.Millisecond
+)
+
+type viewer struct {
+	name        string
+	screen      tcell.Screen
+	model       *model
+	loading     bool
+	readErr     error
+	searching   bool
+	searchStart int
+	query       string
+	lastQuery   string
+	message     string
+}
+
+type logsEvent struct {
+	tcell.EventTime
+	logs []logparser.ParsedLog
+	done bool
+	err  error
+}
+
+// Run shows the logs from the reader in a full-screen terminal viewer, until
+// the user quits. Logs are filtered using the same filter as the printer,
+// and levels can then be toggled on and off in the viewer.
+func Run(name string, r logparser.Reader, filter loglevel.Filter) error {
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return err
+	}
+	if err := screen.Init(); err != nil {
+		return err
+	}
+	defer screen.Fini()
+	v := &viewer{
+		name:    name,
+		screen:  screen,
+		model:   newModel(filter),
+		loading: true,
+	}
+	return v.run(r)
+}
+
+func (v *viewer) run(r logparser.Reader) error {
+	go v.readLogs(r)
+	for {
+		v.draw()
+		switch ev := v.screen.PollEvent().(type) {
+		case nil:
+			return v.readErr
+		case *tcell.EventResize:
+			v.screen.Sync()
+		case *logsEvent:
+			for _, log := range ev.logs {
+				v.model.add(log)
+			}
+			if ev.done {
+				v.loading = false
+				v.readErr = ev.err
+			}
+		case *tcell.EventKey:
+			if quit := v.handleKey(ev); quit {
+				return v.readErr
+			}
+		}
+	}
+}
+
+func (v *viewer) readLogs(r logparser.Reader) {
+	var batch []logparser.ParsedLog
+	lastPost := time.Now()
+	for r.Scan() {
+		batch = append(batch, r.ParsedLog())
+		if len(batch) >= readBatchSize || time.Since(lastPost) >= readBatchDelay {
+			v.postLogs(batch, false, nil)
+			batch = nil
+			lastPost = time.Now()
+		}
+	}
+	v.postLogs(batch, true, r.Err())
+}
+
+func (v *viewer) postLogs(logs []logparser.ParsedLog, done bool, err error) {
+	ev := &logsEvent{logs: logs, done: done, err: err}
+	ev.SetEventNow()
+	v.screen.PostEventWait(ev)
+}
+
+// handleKey updates the state from a key press, and returns true if the
+// viewer should quit.
+func (v *viewer) handleKey(ev *tcell.EventKey) bool {
+	v.message = ""
+	if v.searching {
+		v.handleSearchKey(ev)
+		return false
+	}
+	_, height := v.screen.Size()
+	page := height - 3
+	if page < 1 {
+		page = 1
+	}
+	m := v.model
+	switch ev.Key() {
+	case tcell.KeyCtrlC, tcell.KeyEscape:
+		return true
+	case tcell.KeyUp:
+		m.moveCursor(-1)
+	case tcell.KeyDown:
+		m.moveCursor(1)
+	case tcell.KeyPgUp:
+		m.moveCursor(-page)
+	case tcell.KeyPgDn:
+		m.moveCursor(page)
+	case tcell.KeyHome:
+		m.moveCursor(-len(m.visible))
+	case tcell.KeyEnd:
+		m.moveCursor(len(m.visible))
+	case tcell.KeyEnter:
+		m.toggleExpanded()
+	case tcell.KeyRune:
+		switch r := ev.Rune(); r {
+		case 'q':
+			return true
+		case 'k':
+			m.moveCursor(-1)
+		case 'j':
+			m.moveCursor(1)
+		case 'g':
+			m.moveCursor(-len(m.visible))
+		case 'G':
+			m.moveCursor(len(m.visible))
+		case ' ':
+			m.toggleExpanded()
+		case '/':
+			v.searching = true
+			v.searchStart = m.cursor
+			v.query = ""
+		case 'n', 'N':
+			v.searchAgain(r == 'n')
+		case 'e', 'E':
+			dir := 1
+			if r == 'E' {
+				dir = -1
+			}
+			if !m.findNext(dir, false, isErrorOrAbove) {
+				v.message = "No more errors"
+			}
+		default:
+			for _, t := range toggleKeys {
+				if t.key == r {
+					m.toggleLevel(t.level)
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (v *viewer) handleSearchKey(ev *tcell.EventKey) {
+	switch ev.Key() {
+	case tcell.KeyEscape, tcell.KeyCtrlC:
+		v.searching = false
+		v.model.cursor = v.searchStart
+		return
+	case tcell.KeyEnter:
+		v.searching = false
+		if v.query != "" {
+			v.lastQuery = v.query
+		}
+		return
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if v.query != "" {
+			runes := []rune(v.query)
+			v.query = string(runes[:len(runes)-1])
+		}
+	case tcell.KeyRune:
+		v.query += string(ev.Rune())
+	default:
+		return
+	}
+	v.model.cursor = v.searchStart
+	if v.query != "" && !v.model.findNext(1, true, containsText(v.query)) {
+		v.message = "No match"
+	}
+}
+
+func (v *viewer) searchAgain(forward bool) {
+	if v.lastQuery == "" {
+		v.message = "No previous search"
+		return
+	}
+	dir := 1
+	if !forward {
+		dir = -1
+	}
+	if !v.model.findNext(dir, false, containsText(v.lastQuery)) {
+		v.message = fmt.Sprintf("No more matches for %q", v.lastQuery)
+	}
+}
+
+func (v *viewer) draw() {
+	v.screen.Clear()
+	width, height := v.screen.Size()
+	listHeight := height - 2
+	m := v.model
+	m.scrollToCursor(listHeight)
+
+	row := 0
+	for i := m.top; i < len(m.visible) && row < listHeight; i++ {
+		entry := m.visible[i]
+		log := m.entries[entry]
+		lines := log.Lines
+		if len(lines) == 0 {
+			lines = []string{log.String}
+		}
+		if m.rows(entry) == 1 {
+			lines = lines[:1]
+		}
+		style := levelStyle(log.Level)
+		if i == m.cursor {
+			style = style.Reverse(true)
+		}
+		for j, line := range lines {
+			if row >= listHeight {
+				break
+			}
+			gutter := "  "
+			if j == 0 && len(log.Lines) > 1 {
+				if m.expanded[entry] {
+					gutter = "▾ "
+				} else {
+					gutter = "▸ "
+				}
+			}
+			v.drawText(0, row, width, style, gutter+cleanLine(line), i == m.cursor)
+			row++
+		}
+	}
+
+	statusStyle := tcell.StyleDefault.Reverse(true)
+	v.drawText(0, height-2, width, statusStyle, v.statusText(), true)
+	v.drawBottomLine(height-1, width)
+	v.screen.Show()
+}
+
+func (v *viewer) statusText() string {
+	m := v.model
+	var b strings.Builder
+	fmt.Fprintf(&b, " %s  %d/%d", v.name, len(m.visible), len(m.entries))
+	if len(m.visible) > 0 {
+		fmt.Fprintf(&b, "  line %d", m.entries[m.visible[m.cursor]].LineNumber)
+	}
+	if omitted := formatOmitted(m.omitted); omitted != "" {
+		fmt.Fprintf(&b, "  Omitted: %s", omitted)
+	}
+	if v.loading {
+		b.WriteString("  Loading...")
+	}
+	if v.readErr != nil {
+		fmt.Fprintf(&b, "  Read error: %v", v.readErr)
+	}
+	if v.message != "" {
+		fmt.Fprintf(&b, "  %s", v.message)
+	}
+	return b.String()
+}
+
+func (v *viewer) drawBottomLine(y, width int) {
+	if v.searching {
+		v.drawText(0, y, width, tcell.StyleDefault, "/"+v.query+"█", false)
+		return
+	}
+	x := 0
+	for _, t := range toggleKeys {
+		style := levelStyle(t.level)
+		if v.model.isHidden(t.level) {
+			style = tcell.StyleDefault.Dim(true).StrikeThrough(true)
+		}
+		x = v.drawText(x, y, width, style, fmt.Sprintf("%c:%s", t.key, t.level), false)
+		x = v.drawText(x, y, width, tcell.StyleDefault, " ", false)
+	}
+	v.drawText(x, y, width, tcell.StyleDefault.Dim(true),
+		" /:search n/N:next/prev match e/E:next/prev error enter:expand q:quit", false)
+}
+
+// drawText draws the text and returns the x position after it. If fill is
+// true, the rest of the row is filled using the same style.
+func (v *viewer) drawText(x, y, width int, style tcell.Style, text string, fill bool) int {
+	for _, r := range text {
+		w := runewidth.RuneWidth(r)
+		if x+w > width {
+			return x
+		}
+		v.screen.SetContent(x, y, r, nil, style)
+		x += w
+	}
+	if fill {
+		for ; x < width; x++ {
+			v.screen.SetContent(x, y, ' ', nil, style)
+		}
+	}
+	return x
+}
+
+func cleanLine(line string) string {
+	return strings.ReplaceAll(stripansi.Strip(line), "\t", "    ")
+}
+
+func levelStyle(lvl loglevel.Level) tcell.Style {
+	style := tcell.StyleDefault
+	switch {
+	case lvl >= loglevel.Critical:
+		return style.Foreground(tcell.ColorRed).Bold(true)
+	case lvl >= loglevel.Error:
+		return style.Foreground(tcell.ColorRed)
+	case lvl >= loglevel.Warning:
+		return style.Foreground(tcell.ColorYellow)
+	case lvl >= loglevel.Information:
+		return style
+	case lvl >= loglevel.Trace:
+		return style.Foreground(tcell.ColorGray)
+	default:
+		return style
+	}
+}
+
+func formatOmitted(omitted map[loglevel.Level]int) string {
+	levels := make([]loglevel.Level, 0, len(omitted))
+	for lvl, count := range omitted {
+		if count > 0 {
+			levels = append(levels, lvl)
+		}
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i] < levels[j] })
+	parts := make([]string, len(levels))
+	for i, lvl := range levels {
+		parts[i] = fmt.Sprintf("%s=%d", lvl, omitted[lvl])
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,232 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package where
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jilleJr/flog/pkg/loglevel"
+	"github.com/jilleJr/flog/pkg/logparser"
+)
+
+// Expr is a parsed expression that can be evaluated against logs.
+type Expr interface {
+	Match(log logparser.ParsedLog) bool
+	String() string
+}
+
+type andExpr struct {
+	left, right Expr
+}
+
+func (e andExpr) Match(log logparser.ParsedLog) bool {
+	return e.left.Match(log) && e.right.Match(log)
+}
+
+func (e andExpr) String() string {
+	return fmt.Sprintf("(%s and %s)", e.left, e.right)
+}
+
+type orExpr struct {
+	left, right Expr
+}
+
+func (e orExpr) Match(log logparser.ParsedLog) bool {
+	return e.left.Match(log) || e.right.Match(log)
+}
+
+func (e orExpr) String() string {
+	return fmt.Sprintf("(%s or %s)", e.left, e.right)
+}
+
+type notExpr struct {
+	inner Expr
+}
+
+func (e notExpr) Match(log logparser.ParsedLog) bool {
+	return !e.inner.Match(log)
+}
+
+func (e notExpr) String() string {
+	return fmt.Sprintf("not %s", e.inner)
+}
+
+type existsExpr struct {
+	field string
+}
+
+func (e existsExpr) Match(log logparser.ParsedLog) bool {
+	_, ok := lookupField(log, e.field)
+	return ok
+}
+
+func (e existsExpr) String() string {
+	return fmt.Sprintf("%s exists", e.field)
+}
+
+type regexExpr struct {
+	field string
+	re    *regexp.Regexp
+}
+
+func (e regexExpr) Match(log logparser.ParsedLog) bool {
+	value, ok := lookupField(log, e.field)
+	return ok && e.re.MatchString(valueString(value))
+}
+
+func (e regexExpr) String() string {
+	return fmt.Sprintf("%s =~ %q", e.field, e.re)
+}
+
+type compareExpr struct {
+	field string
+	op    string
+	value string
+	// number is true if the value was written as a number, in which case
+	// values that are not numbers cannot be ordered against it.
+	number bool
+}
+
+func (e compareExpr) Match(log logparser.ParsedLog) bool {
+	value, ok := lookupField(log, e.field)
+	if !ok {
+		return e.op == "!="
+	}
+	cmp, ordered := compare(value, e.value)
+	if e.number && !ordered && e.op != "==" && e.op != "!=" {
+		return false
+	}
+	switch e.op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case ">":
+		return cmp > 0
+	case "<=":
+		return cmp <= 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+func (e compareExpr) String() string {
+	return fmt.Sprintf("%s %s %q", e.field, e.op, e.value)
+}
+
+// compare returns -1, 0, or 1. Numbers are compared numerically, severities
+// are compared by their order, and anything else is compared as strings,
+// in which case false is returned as well.
+func compare(value any, other string) (int, bool) {
+	if lvl, ok := value.(loglevel.Level); ok {
+		if otherLvl := loglevel.ParseLevel(other); otherLvl != loglevel.Unknown {
+			return compareOrdered(lvl, otherLvl), true
+		}
+	}
+	str := valueString(value)
+	if num, err := strconv.ParseFloat(str, 64); err == nil {
+		if otherNum, err := strconv.ParseFloat(other, 64); err == nil {
+			return compareOrdered(num, otherNum), true
+		}
+	}
+	return strings.Compare(str, other), false
+}
+
+func compareOrdered[T loglevel.Level | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func valueString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case loglevel.Level:
+		return v.String()
+	}
+	return fmt.Sprint(value)
+}
+
+// lookupField finds a field by its name. Dotted names, such as "http.status",
+// are looked up in nested objects if no field has the full dotted name.
+// The names "message", "logger", "caller", and "level" fall back to the
+// values parsed from the log.
+func lookupField(log logparser.ParsedLog, name string) (any, bool) {
+	if value, ok := lookupNested(log.Fields, name); ok {
+		return value, true
+	}
+	switch name {
+	case "msg", "message":
+		return log.Message, log.Message != ""
+	case "logger":
+		return log.Logger, log.Logger != ""
+	case "caller":
+		return log.Caller, log.Caller != ""
+	case "level":
+		return log.Level, log.Level != loglevel.Undefined
+	}
+	return nil, false
+}
+
+func lookupNested(fields map[string]any, name string) (any, bool) {
+	if value, ok := fields[name]; ok {
+		return value, value != nil
+	}
+	for i := strings.IndexByte(name, '.'); i != -1; i = nextDot(name, i) {
+		if nested, ok := fields[name[:i]].(map[string]any); ok {
+			if value, ok := lookupNested(nested, name[i+1:]); ok {
+				return value, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func nextDot(name string, i int) int {
+	next := strings.IndexByte(name[i+1:], '.')
+	if next == -1 {
+		return -1
+	}
+	return i + 1 + next
+}
+
+// All combines expressions so that all of them must match. Returns nil if
+// no expressions are given.
+func All(exprs ...Expr) Expr {
+	if len(exprs) == 0 {
+		return nil
+	}
+	e := exprs[0]
+	for _, right := range exprs[1:] {
+		e = andExpr{e, right}
+	}
+	return e
+}
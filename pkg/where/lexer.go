@@ -0,0 +1,197 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package where
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind byte
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenExists
+)
+
+func (k tokenKind) String() string {
+	switch k {
+	case tokenEOF:
+		return "end of expression"
+	case tokenIdent:
+		return "field name"
+	case tokenString:
+		return "string"
+	case tokenNumber:
+		return "number"
+	case tokenOperator:
+		return "operator"
+	case tokenLParen:
+		return `"("`
+	case tokenRParen:
+		return `")"`
+	case tokenAnd:
+		return `"and"`
+	case tokenOr:
+		return `"or"`
+	case tokenNot:
+		return `"not"`
+	case tokenExists:
+		return `"exists"`
+	}
+	return "unknown token"
+}
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF, tokenLParen, tokenRParen, tokenAnd, tokenOr, tokenNot, tokenExists:
+		return t.kind.String()
+	}
+	return fmt.Sprintf("%s %q", t.kind, t.value)
+}
+
+var operators = []string{"==", "!=", "<=", ">=", "=~", "<", ">", "&&", "||", "!"}
+
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, value: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, value: ")", pos: i})
+			i++
+		case r == '"' || r == '\'':
+			str, end, err := readString(runes, i)
+			if err != nil {
+				return nil, &SyntaxError{Expr: expr, Pos: i, Msg: err.Error()}
+			}
+			tokens = append(tokens, token{kind: tokenString, value: str, pos: i})
+			i = end
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, value: string(runes[start:i]), pos: start})
+		case isIdentStart(r):
+			start := i
+			for i < len(runes) && isIdentPart(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, identOrKeyword(string(runes[start:i]), start))
+		default:
+			op := matchOperator(runes[i:])
+			if op == "" {
+				return nil, &SyntaxError{Expr: expr, Pos: i, Msg: fmt.Sprintf("unexpected character %q", r)}
+			}
+			switch op {
+			case "&&":
+				tokens = append(tokens, token{kind: tokenAnd, value: op, pos: i})
+			case "||":
+				tokens = append(tokens, token{kind: tokenOr, value: op, pos: i})
+			case "!":
+				tokens = append(tokens, token{kind: tokenNot, value: op, pos: i})
+			default:
+				tokens = append(tokens, token{kind: tokenOperator, value: op, pos: i})
+			}
+			i += len(op)
+		}
+	}
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(runes)})
+	return tokens, nil
+}
+
+func readString(runes []rune, start int) (string, int, error) {
+	quote := runes[start]
+	var b strings.Builder
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				b.WriteRune(runes[i])
+			}
+		case quote:
+			return b.String(), i + 1, nil
+		default:
+			b.WriteRune(runes[i])
+		}
+	}
+	return "", 0, fmt.Errorf("missing closing quote %q", quote)
+}
+
+func matchOperator(runes []rune) string {
+	for _, op := range operators {
+		if strings.HasPrefix(string(runes[:min(len(runes), 2)]), op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func isIdentStart(r rune) bool {
+	return unicode.IsLetter(r) || r == '_' || r == '@'
+}
+
+func isIdentPart(r rune) bool {
+	return isIdentStart(r) || unicode.IsDigit(r) || r == '.' || r == '-'
+}
+
+func identOrKeyword(value string, pos int) token {
+	switch strings.ToLower(value) {
+	case "and":
+		return token{kind: tokenAnd, value: value, pos: pos}
+	case "or":
+		return token{kind: tokenOr, value: value, pos: pos}
+	case "not":
+		return token{kind: tokenNot, value: value, pos: pos}
+	case "exists":
+		return token{kind: tokenExists, value: value, pos: pos}
+	}
+	return token{kind: tokenIdent, value: value, pos: pos}
+}
@@ -0,0 +1,176 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package where implements a small expression language used to filter logs
+// on their fields, such as:
+//
+//	service == "billing" and (status >= 500 or user_id exists)
+package where
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SyntaxError is returned when an expression could not be parsed.
+type SyntaxError struct {
+	Expr string
+	Pos  int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at position %d\n  %s\n  %s^",
+		e.Msg, e.Pos+1, e.Expr, strings.Repeat(" ", e.Pos))
+}
+
+// Parse parses an expression. The following operators are supported:
+//
+//	field == value    field != value    field =~ regex    field exists
+//	field < value     field > value     field <= value    field >= value
+//	expr and expr     expr or expr      not expr          (expr)
+func Parse(expr string) (Expr, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := parser{expr: expr, tokens: tokens}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorf(tok, "unexpected %s, expected \"and\", \"or\", or end of expression", tok)
+	}
+	return e, nil
+}
+
+type parser struct {
+	expr   string
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) pop() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) errorf(tok token, format string, args ...any) error {
+	return &SyntaxError{Expr: p.expr, Pos: tok.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		p.pop()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenAnd {
+		p.pop()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	if p.peek().kind == tokenNot {
+		p.pop()
+		inner, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{inner}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	tok := p.pop()
+	switch tok.kind {
+	case tokenLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.pop(); closing.kind != tokenRParen {
+			return nil, p.errorf(closing, "unexpected %s, expected \")\" to match \"(\" at position %d", closing, tok.pos+1)
+		}
+		return inner, nil
+	case tokenIdent:
+		return p.parseCondition(tok.value)
+	default:
+		return nil, p.errorf(tok, "unexpected %s, expected field name, \"not\", or \"(\"", tok)
+	}
+}
+
+func (p *parser) parseCondition(field string) (Expr, error) {
+	tok := p.pop()
+	switch tok.kind {
+	case tokenExists:
+		return existsExpr{field}, nil
+	case tokenOperator:
+	default:
+		return nil, p.errorf(tok, "unexpected %s after field %q, expected comparison operator or \"exists\"", tok, field)
+	}
+	valueTok := p.pop()
+	switch valueTok.kind {
+	case tokenString, tokenNumber, tokenIdent:
+	default:
+		return nil, p.errorf(valueTok, "unexpected %s after %q, expected value", valueTok, tok.value)
+	}
+	if tok.value == "=~" {
+		re, err := regexp.Compile(valueTok.value)
+		if err != nil {
+			return nil, p.errorf(valueTok, "invalid regex: %v", err)
+		}
+		return regexExpr{field, re}, nil
+	}
+	return compareExpr{
+		field:  field,
+		op:     tok.value,
+		value:  valueTok.value,
+		number: valueTok.kind == tokenNumber,
+	}, nil
+}
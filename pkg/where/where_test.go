@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: 2022 Kalle Fagerberg
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package where
+
+import (
+	"testing"
+
+	"github.com/jilleJr/flog/pkg/loglevel"
+	"github.com/jilleJr/flog/pkg/logparser"
+)
+
+func TestMatch(t *testing.T) {
+	log := logparser.ParsedLog{
+		Level:   loglevel.Warning,
+		Message: "payment failed",
+		Fields: map[string]any{
+			"service": "billing",
+			"status":  float64(502),
+			"code":    "404",
+			"result":  "abc",
+			"http": map[string]any{
+				"method": "POST",
+			},
+		},
+	}
+	testCases := []struct {
+		expr string
+		want bool
+	}{
+		{expr: `service == "billing"`, want: true},
+		{expr: `service == billing`, want: true},
+		{expr: `service != "billing"`, want: false},
+		{expr: `missing != "billing"`, want: true},
+		{expr: `missing == "billing"`, want: false},
+		{expr: `status >= 500`, want: true},
+		{expr: `status < 500`, want: false},
+		{expr: `code > 99`, want: true},
+		{expr: `result > 500`, want: false},
+		{expr: `result < 500`, want: false},
+		{expr: `result >= 500`, want: false},
+		{expr: `result != 500`, want: true},
+		{expr: `result > "aaa"`, want: true},
+		{expr: `service < "orders"`, want: true},
+		{expr: `service exists`, want: true},
+		{expr: `user_id exists`, want: false},
+		{expr: `not user_id exists`, want: true},
+		{expr: `message =~ "^payment"`, want: true},
+		{expr: `http.method == POST`, want: true},
+		{expr: `level >= warn`, want: true},
+		{expr: `level > warn`, want: false},
+		{expr: `service == "billing" and status >= 500`, want: true},
+		{expr: `service == "orders" or status >= 500`, want: true},
+		{expr: `service == "orders" or (status >= 500 and user_id exists)`, want: false},
+		{expr: `!(service == "orders") && status == 502`, want: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			expr, err := Parse(tc.expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := expr.Match(log); got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParse_errors(t *testing.T) {
+	testCases := []struct {
+		expr string
+		pos  int
+	}{
+		{expr: `status ==`, pos: 9},
+		{expr: `status 500`, pos: 7},
+		{expr: `(status == 500`, pos: 14},
+		{expr: `status == 500 service`, pos: 14},
+		{expr: `service == "billing`, pos: 11},
+		{expr: `message =~ "("`, pos: 11},
+		{expr: `and`, pos: 0},
+		{expr: `status # 5`, pos: 7},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, err := Parse(tc.expr)
+			syntaxErr, ok := err.(*SyntaxError)
+			if !ok {
+				t.Fatalf("expected *SyntaxError, got: %#v", err)
+			}
+			if syntaxErr.Pos != tc.pos {
+				t.Errorf("wrong position\nwanted: %d\ngot:    %d\n%s", tc.pos, syntaxErr.Pos, syntaxErr)
+			}
+		})
+	}
+}